@@ -0,0 +1,270 @@
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 默认配置值
+const (
+	DefaultMinRequests      = 10
+	DefaultOpenTimeout      = 30 * time.Second
+	DefaultHalfOpenRequests = 1
+)
+
+// State 熔断器状态
+type State int
+
+const (
+	// StateClosed 闭合：请求正常通过
+	StateClosed State = iota
+	// StateHalfOpen 半开：仅允许少量探测请求
+	StateHalfOpen
+	// StateOpen 打开：请求直接失败
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// ErrCircuitOpen 熔断器打开时返回的错误，可通过 errors.Is 判断
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// OpenError 熔断器打开错误，包含熔断器名称
+type OpenError struct {
+	Name string
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("circuit breaker is open: [%s]", e.Name)
+}
+
+// Is 使 errors.Is(err, ErrCircuitOpen) 成立
+func (e *OpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// Counts 当前统计周期内的请求计数
+type Counts struct {
+	Requests             int
+	Successes            int
+	Failures             int
+	ConsecutiveSuccesses int
+	ConsecutiveFailures  int
+}
+
+// StateChangeFunc 状态变化回调（在熔断器锁内执行，回调中不要再调用该熔断器的方法）
+type StateChangeFunc func(name string, from, to State)
+
+// Breaker 熔断器
+type Breaker struct {
+	name     string
+	cfg      Config
+	onChange StateChangeFunc
+	now      func() time.Time // 时钟，测试时可替换
+
+	mu         sync.Mutex
+	state      State
+	generation uint64
+	counts     Counts
+	expiry     time.Time
+}
+
+// New 创建熔断器，onChange 可为 nil
+func New(name string, c *Config, onChange StateChangeFunc) *Breaker {
+	cfg := *c
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = DefaultMinRequests
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = int(DefaultOpenTimeout / time.Millisecond)
+	}
+	if cfg.HalfOpenRequests <= 0 {
+		cfg.HalfOpenRequests = DefaultHalfOpenRequests
+	}
+
+	b := &Breaker{name: name, cfg: cfg, onChange: onChange, now: time.Now}
+	b.newGeneration(b.now())
+	return b
+}
+
+// Name 返回熔断器名称
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State 返回当前状态
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state, _ := b.currentState(b.now())
+	return state
+}
+
+// Counts 返回当前统计周期的计数
+func (b *Breaker) Counts() Counts {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.counts
+}
+
+// Check 熔断器打开时返回 *OpenError，不占用探测名额
+func (b *Breaker) Check() error {
+	if b.State() == StateOpen {
+		return &OpenError{Name: b.name}
+	}
+	return nil
+}
+
+// Allow 申请执行一次请求，返回的 generation 需传给 Record
+func (b *Breaker) Allow() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state, generation := b.currentState(b.now())
+	if state == StateOpen {
+		return generation, &OpenError{Name: b.name}
+	}
+	if state == StateHalfOpen && b.counts.Requests >= b.cfg.HalfOpenRequests {
+		return generation, &OpenError{Name: b.name}
+	}
+	b.counts.Requests++
+	return generation, nil
+}
+
+// Record 记录 Allow 放行的请求结果，过期周期的结果会被忽略
+func (b *Breaker) Record(generation uint64, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	state, current := b.currentState(now)
+	if generation != current {
+		return
+	}
+	b.record(state, success, now)
+}
+
+// Report 记录一次未经过 Allow 的请求结果（如驱动事件回调）
+func (b *Breaker) Report(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	state, _ := b.currentState(now)
+	if state == StateOpen {
+		return
+	}
+	b.counts.Requests++
+	b.record(state, success, now)
+}
+
+// Execute 在熔断器保护下执行 fn，isFailure 为 nil 时所有错误都计为失败
+func (b *Breaker) Execute(fn func() error, isFailure func(error) bool) error {
+	generation, err := b.Allow()
+	if err != nil {
+		return err
+	}
+	err = fn()
+	failed := err != nil
+	if failed && isFailure != nil {
+		failed = isFailure(err)
+	}
+	b.Record(generation, !failed)
+	return err
+}
+
+// record 更新计数并判断是否需要切换状态
+func (b *Breaker) record(state State, success bool, now time.Time) {
+	if success {
+		b.counts.Successes++
+		b.counts.ConsecutiveSuccesses++
+		b.counts.ConsecutiveFailures = 0
+		if state == StateHalfOpen && b.counts.ConsecutiveSuccesses >= b.cfg.HalfOpenRequests {
+			b.setState(StateClosed, now)
+		}
+		return
+	}
+
+	b.counts.Failures++
+	b.counts.ConsecutiveFailures++
+	b.counts.ConsecutiveSuccesses = 0
+	switch state {
+	case StateClosed:
+		if b.tripped() {
+			b.setState(StateOpen, now)
+		}
+	case StateHalfOpen:
+		b.setState(StateOpen, now)
+	}
+}
+
+// tripped 判断闭合状态下是否达到熔断条件
+func (b *Breaker) tripped() bool {
+	if b.cfg.ConsecutiveFailures > 0 && b.counts.ConsecutiveFailures >= b.cfg.ConsecutiveFailures {
+		return true
+	}
+	if b.cfg.ErrorRate > 0 && b.counts.Requests >= b.cfg.MinRequests {
+		return float64(b.counts.Failures)/float64(b.counts.Requests) >= b.cfg.ErrorRate
+	}
+	return false
+}
+
+// currentState 返回当前状态，并处理统计窗口和熔断时间到期
+func (b *Breaker) currentState(now time.Time) (State, uint64) {
+	switch b.state {
+	case StateClosed:
+		if !b.expiry.IsZero() && b.expiry.Before(now) {
+			b.newGeneration(now)
+		}
+	case StateOpen:
+		if b.expiry.Before(now) {
+			b.setState(StateHalfOpen, now)
+		}
+	}
+	return b.state, b.generation
+}
+
+// setState 切换状态并开始新的统计周期
+func (b *Breaker) setState(state State, now time.Time) {
+	if b.state == state {
+		return
+	}
+	prev := b.state
+	b.state = state
+	b.newGeneration(now)
+	if b.onChange != nil {
+		b.onChange(b.name, prev, state)
+	}
+}
+
+// newGeneration 开始新的统计周期
+func (b *Breaker) newGeneration(now time.Time) {
+	b.generation++
+	b.counts = Counts{}
+
+	switch b.state {
+	case StateClosed:
+		if b.cfg.Interval > 0 {
+			b.expiry = now.Add(time.Millisecond * time.Duration(b.cfg.Interval))
+		} else {
+			b.expiry = time.Time{}
+		}
+	case StateOpen:
+		b.expiry = now.Add(time.Millisecond * time.Duration(b.cfg.OpenTimeout))
+	default:
+		b.expiry = time.Time{}
+	}
+}
@@ -0,0 +1,101 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var errFail = errors.New("fail")
+
+// fakeClock 手动推进的时钟
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.t }
+
+func (c *fakeClock) Advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// newTestBreaker 创建使用手动时钟的熔断器
+func newTestBreaker(c *Config, onChange StateChangeFunc) (*Breaker, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	b := New("test", c, onChange)
+	b.now = clock.Now
+	b.newGeneration(clock.Now())
+	return b, clock
+}
+
+func TestConsecutiveFailures(t *testing.T) {
+	var changes []State
+	b, clock := newTestBreaker(&Config{ConsecutiveFailures: 3, OpenTimeout: 20}, func(_ string, _, to State) {
+		changes = append(changes, to)
+	})
+
+	for i := 0; i < 3; i++ {
+		_ = b.Execute(func() error { return errFail }, nil)
+	}
+	if b.State() != StateOpen {
+		t.Fatalf("state = %s, want open", b.State())
+	}
+	if err := b.Execute(func() error { return nil }, nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+
+	// 熔断时间到期后进入半开状态，探测成功后恢复
+	clock.Advance(30 * time.Millisecond)
+	if b.State() != StateHalfOpen {
+		t.Fatalf("state = %s, want half-open", b.State())
+	}
+	if err := b.Execute(func() error { return nil }, nil); err != nil {
+		t.Fatalf("probe err = %v", err)
+	}
+	if b.State() != StateClosed {
+		t.Fatalf("state = %s, want closed", b.State())
+	}
+	if len(changes) != 3 {
+		t.Fatalf("changes = %v", changes)
+	}
+}
+
+func TestErrorRate(t *testing.T) {
+	b := New("test", &Config{ErrorRate: 0.5, MinRequests: 4}, nil)
+
+	_ = b.Execute(func() error { return nil }, nil)
+	_ = b.Execute(func() error { return errFail }, nil)
+	_ = b.Execute(func() error { return nil }, nil)
+	if b.State() != StateClosed {
+		t.Fatalf("state = %s, want closed before min requests", b.State())
+	}
+	_ = b.Execute(func() error { return errFail }, nil)
+	if b.State() != StateOpen {
+		t.Fatalf("state = %s, want open", b.State())
+	}
+}
+
+func TestHalfOpenFailure(t *testing.T) {
+	b, clock := newTestBreaker(&Config{ConsecutiveFailures: 1, OpenTimeout: 10, HalfOpenRequests: 1}, nil)
+	_ = b.Execute(func() error { return errFail }, nil)
+	clock.Advance(20 * time.Millisecond)
+
+	generation, err := b.Allow()
+	if err != nil {
+		t.Fatalf("probe rejected: %v", err)
+	}
+	// 半开状态下超出探测名额的请求被拒绝
+	if _, err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	b.Record(generation, false)
+	if b.State() != StateOpen {
+		t.Fatalf("state = %s, want open", b.State())
+	}
+}
+
+func TestIgnoredErrors(t *testing.T) {
+	b := New("test", &Config{ConsecutiveFailures: 1}, nil)
+	_ = b.Execute(func() error { return errFail }, func(error) bool { return false })
+	if b.State() != StateClosed {
+		t.Fatalf("state = %s, want closed", b.State())
+	}
+}
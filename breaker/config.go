@@ -0,0 +1,10 @@
+package breaker
+
+type Config struct {
+	ConsecutiveFailures int     `toml:"consecutive_failures" yaml:"consecutive_failures" json:"consecutive_failures"` // 连续失败次数达到该值时熔断，0 表示不启用
+	ErrorRate           float64 `toml:"error_rate" yaml:"error_rate" json:"error_rate"`                               // 统计窗口内错误率（0~1）达到该值时熔断，0 表示不启用
+	MinRequests         int     `toml:"min_requests" yaml:"min_requests" json:"min_requests"`                         // 计算错误率所需的最小请求数，默认 10
+	Interval            int     `toml:"interval" yaml:"interval" json:"interval"`                                     // 闭合状态下的统计窗口（单位：毫秒），到期清零计数，0 表示不清零
+	OpenTimeout         int     `toml:"open_timeout" yaml:"open_timeout" json:"open_timeout"`                         // 熔断持续时间（单位：毫秒），到期后进入半开状态，默认 30000
+	HalfOpenRequests    int     `toml:"half_open_requests" yaml:"half_open_requests" json:"half_open_requests"`       // 半开状态允许通过的探测请求数，全部成功后恢复，默认 1
+}
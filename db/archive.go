@@ -0,0 +1,321 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/restoflife/ql_common/mongo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	driver "go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.uber.org/zap"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// 归档任务默认值
+const (
+	DefaultArchiveBatchSize  = 500
+	DefaultArchiveCheckpoint = "archive_checkpoint"
+)
+
+// ArchiveJob 将 MySQL 表中早于截止时间的数据分批迁移到 Mongo 集合：
+// 每批先写入 Mongo（文档 _id 为主键），核对写入条数后再删除源数据，并记录检查点，中断后可继续执行
+type ArchiveJob struct {
+	Name       string    // 数据库名称
+	Table      string    // 源表名
+	PK         string    // 单列递增主键，为空时使用 id
+	TimeColumn string    // 判断数据新旧的时间字段，如 created_at
+	Before     time.Time // 截止时间，早于该时间的数据会被归档
+
+	Mongo      string // mongo 实例名称
+	Database   string // 目标库名
+	Collection string // 目标集合名
+	Checkpoint string // 检查点集合名（与目标集合同库），为空时使用 archive_checkpoint
+
+	BatchSize int           // 每批条数，为 0 时使用 DefaultArchiveBatchSize
+	Sleep     time.Duration // 每批之间的间隔
+	MaxLag    time.Duration // 从库复制延迟超过该值时暂停，0 表示不检查
+	DryRun    bool          // 只读取和记录日志，不写入、不删除、不保存检查点
+
+	Logger *zap.Logger // 进度日志，为空时使用 SQL 日志
+}
+
+// ArchiveResult 归档结果
+type ArchiveResult struct {
+	Batches  int   // 处理的批次数
+	Archived int64 // 归档（DryRun 时为待归档）的行数
+	LastPK   any   // 最后处理的主键
+}
+
+// archiveCheckpoint 检查点文档
+type archiveCheckpoint struct {
+	Id        string    `bson:"_id"`
+	LastPK    any       `bson:"last_pk"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+// Run 执行归档直到没有满足条件的数据或 ctx 取消
+func (j *ArchiveJob) Run(ctx context.Context) (*ArchiveResult, error) {
+	if j.Table == "" || j.TimeColumn == "" || j.Before.IsZero() {
+		return nil, errors.New("archive: table, time column and cutoff are required")
+	}
+	if _, err := get(j.Name); err != nil {
+		return nil, err
+	}
+	target, err := mongo.GetCollection(j.Mongo, j.Database, j.Collection)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ArchiveResult{}
+	if result.LastPK, err = j.loadCheckpoint(ctx); err != nil {
+		return nil, err
+	}
+	j.log("归档开始", zap.Any("last_pk", result.LastPK), zap.Time("before", j.Before), zap.Bool("dry_run", j.DryRun))
+
+	for {
+		if err = ctx.Err(); err != nil {
+			return result, err
+		}
+		if err = j.waitReplica(ctx); err != nil {
+			return result, err
+		}
+
+		rows, err := j.fetch(ctx, result.LastPK)
+		if err != nil {
+			return result, err
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		ids := make([]any, len(rows))
+		docs := make([]any, len(rows))
+		for i, row := range rows {
+			doc := make(bson.M, len(row)+1)
+			for k, v := range row {
+				if b, ok := v.([]byte); ok {
+					v = string(b)
+				}
+				doc[k] = v
+			}
+			ids[i] = row[j.pk()]
+			doc["_id"] = ids[i]
+			docs[i] = doc
+		}
+		lastPK := ids[len(ids)-1]
+
+		if !j.DryRun {
+			if err = j.copy(ctx, target, ids, docs); err != nil {
+				return result, err
+			}
+			if err = j.delete(ctx, ids); err != nil {
+				return result, err
+			}
+			if err = j.saveCheckpoint(ctx, lastPK); err != nil {
+				return result, err
+			}
+		}
+
+		result.Batches++
+		result.Archived += int64(len(rows))
+		result.LastPK = lastPK
+		j.log("归档进度",
+			zap.Int("batch", result.Batches),
+			zap.Int("rows", len(rows)),
+			zap.Int64("total", result.Archived),
+			zap.Any("last_pk", lastPK),
+		)
+
+		if len(rows) < j.batchSize() {
+			break
+		}
+		if j.Sleep > 0 {
+			select {
+			case <-ctx.Done():
+				return result, ctx.Err()
+			case <-time.After(j.Sleep):
+			}
+		}
+	}
+
+	j.log("归档完成", zap.Int("batches", result.Batches), zap.Int64("total", result.Archived))
+	return result, nil
+}
+
+// fetch 读取下一批待归档的数据
+func (j *ArchiveJob) fetch(ctx context.Context, lastPK any) ([]map[string]any, error) {
+	session, err := NewSessionContext(ctx, j.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer Close(session)
+
+	var cond builder.Cond = builder.Lt{j.TimeColumn: j.Before}
+	if lastPK != nil {
+		cond = cond.And(builder.Gt{j.pk(): lastPK})
+	}
+	rows, err := session.Table(j.Table).Where(cond).OrderBy(j.pk()).Limit(j.batchSize()).QueryInterface()
+	if err != nil {
+		return nil, WrapTimeout(session, err)
+	}
+	return rows, nil
+}
+
+// copy 写入 Mongo 并核对条数；上次中断时已写入的文档会因主键重复被跳过
+func (j *ArchiveJob) copy(ctx context.Context, target *driver.Collection, ids, docs []any) error {
+	_, err := target.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err != nil && !driver.IsDuplicateKeyError(err) {
+		return fmt.Errorf("archive: insert into mongo: %w", err)
+	}
+	n, err := target.CountDocuments(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return fmt.Errorf("archive: verify mongo: %w", err)
+	}
+	if n != int64(len(ids)) {
+		return fmt.Errorf("archive: verify mongo: expected %d documents, found %d", len(ids), n)
+	}
+	return nil
+}
+
+// delete 删除已归档的源数据（再次校验时间条件，避免误删被更新过的行）
+func (j *ArchiveJob) delete(ctx context.Context, ids []any) error {
+	session, err := NewSessionContext(ctx, j.Name)
+	if err != nil {
+		return err
+	}
+	defer Close(session)
+
+	cond := builder.In(j.pk(), ids...).And(builder.Lt{j.TimeColumn: j.Before})
+	if _, err = session.Exec(builder.Delete(cond).From(j.Table)); err != nil {
+		return WrapTimeout(session, err)
+	}
+	return nil
+}
+
+// waitReplica 从库复制延迟超过 MaxLag 时等待
+func (j *ArchiveJob) waitReplica(ctx context.Context) error {
+	if j.MaxLag <= 0 {
+		return nil
+	}
+	g, err := get(j.Name)
+	if err != nil {
+		return err
+	}
+	for {
+		lag, err := replicaLag(ctx, g.Slaves())
+		if err != nil {
+			return err
+		}
+		if lag <= j.MaxLag {
+			return nil
+		}
+		j.log("从库延迟过高，暂停归档", zap.Duration("lag", lag), zap.Duration("max_lag", j.MaxLag))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(j.MaxLag):
+		}
+	}
+}
+
+// replicaLag 返回所有从库中最大的复制延迟，复制中断时视为无限大
+func replicaLag(ctx context.Context, slaves []*xorm.Engine) (time.Duration, error) {
+	var maxLag time.Duration
+	for _, s := range slaves {
+		rows, err := s.Context(ctx).QueryString("SHOW REPLICA STATUS")
+		if err != nil {
+			// MySQL 8.0.22 之前的版本
+			if rows, err = s.Context(ctx).QueryString("SHOW SLAVE STATUS"); err != nil {
+				return 0, err
+			}
+		}
+		for _, row := range rows {
+			v, ok := row["Seconds_Behind_Source"]
+			if !ok {
+				v = row["Seconds_Behind_Master"]
+			}
+			sec, err := strconv.Atoi(v)
+			if err != nil {
+				return time.Duration(math.MaxInt64), nil
+			}
+			if d := time.Duration(sec) * time.Second; d > maxLag {
+				maxLag = d
+			}
+		}
+	}
+	return maxLag, nil
+}
+
+// loadCheckpoint 读取上次处理到的主键
+func (j *ArchiveJob) loadCheckpoint(ctx context.Context) (any, error) {
+	coll, err := mongo.GetCollection(j.Mongo, j.Database, j.checkpoint())
+	if err != nil {
+		return nil, err
+	}
+	var cp archiveCheckpoint
+	err = coll.FindOne(ctx, bson.M{"_id": j.checkpointID()}).Decode(&cp)
+	if errors.Is(err, driver.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("archive: load checkpoint: %w", err)
+	}
+	return cp.LastPK, nil
+}
+
+// saveCheckpoint 保存处理到的主键
+func (j *ArchiveJob) saveCheckpoint(ctx context.Context, lastPK any) error {
+	coll, err := mongo.GetCollection(j.Mongo, j.Database, j.checkpoint())
+	if err != nil {
+		return err
+	}
+	cp := archiveCheckpoint{Id: j.checkpointID(), LastPK: lastPK, UpdatedAt: time.Now()}
+	_, err = coll.ReplaceOne(ctx, bson.M{"_id": cp.Id}, cp, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("archive: save checkpoint: %w", err)
+	}
+	return nil
+}
+
+func (j *ArchiveJob) pk() string {
+	if j.PK == "" {
+		return "id"
+	}
+	return j.PK
+}
+
+func (j *ArchiveJob) batchSize() int {
+	if j.BatchSize <= 0 {
+		return DefaultArchiveBatchSize
+	}
+	return j.BatchSize
+}
+
+func (j *ArchiveJob) checkpoint() string {
+	if j.Checkpoint == "" {
+		return DefaultArchiveCheckpoint
+	}
+	return j.Checkpoint
+}
+
+// checkpointID 检查点按 数据库:表:集合 区分
+func (j *ArchiveJob) checkpointID() string {
+	return j.Name + ":" + j.Table + ":" + j.Collection
+}
+
+func (j *ArchiveJob) log(msg string, fields ...zap.Field) {
+	l := j.Logger
+	if l == nil {
+		l = sqlLogger
+	}
+	if l == nil {
+		return
+	}
+	l.Info(msg, append([]zap.Field{zap.String("name", j.Name), zap.String("table", j.Table)}, fields...)...)
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"xorm.io/xorm"
+)
+
+type archiveLog struct {
+	Id        int64
+	Msg       string    `xorm:"varchar(64)"`
+	CreatedAt time.Time `xorm:"'created_at'"`
+}
+
+func TestArchiveBatches(t *testing.T) {
+	engine, err := xorm.NewEngine("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine.SetMaxOpenConns(1)
+	g, err := xorm.NewEngineGroup(engine, []*xorm.Engine{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = Register("archive_test", g, &XORMConfigLite{}); err != nil {
+		t.Fatal(err)
+	}
+	defer Unregister("archive_test")
+	if err = g.Sync(new(archiveLog)); err != nil {
+		t.Fatal(err)
+	}
+
+	cutoff := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	old, recent := cutoff.Add(-time.Hour), cutoff.Add(time.Hour)
+	for i, at := range []time.Time{old, recent, old, old, old} {
+		if _, err = g.Insert(&archiveLog{Id: int64(i + 1), Msg: "m", CreatedAt: at}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := context.Background()
+	j := &ArchiveJob{Name: "archive_test", Table: "archive_log", TimeColumn: "created_at", Before: cutoff, BatchSize: 2}
+	if _, err = (&ArchiveJob{Name: "archive_test"}).Run(ctx); err == nil {
+		t.Fatal("expected missing table error")
+	}
+
+	// 按主键分批读取早于截止时间的数据，跳过新数据
+	var ids []any
+	var lastPK any
+	for {
+		rows, err := j.fetch(ctx, lastPK)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) == 0 {
+			break
+		}
+		if len(rows) > j.BatchSize {
+			t.Fatalf("batch size = %d", len(rows))
+		}
+		for _, row := range rows {
+			ids = append(ids, row["id"])
+		}
+		lastPK = rows[len(rows)-1]["id"]
+	}
+	if len(ids) != 4 || ids[0] != int64(1) || ids[1] != int64(3) || ids[3] != int64(5) {
+		t.Fatalf("ids = %v", ids)
+	}
+
+	// 读取后被更新为新数据的行不会被删除
+	if _, err = g.ID(5).Cols("created_at").Update(&archiveLog{CreatedAt: recent}); err != nil {
+		t.Fatal(err)
+	}
+	if err = j.delete(ctx, ids); err != nil {
+		t.Fatal(err)
+	}
+	var left []archiveLog
+	if err = g.OrderBy("id").Find(&left); err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 2 || left[0].Id != 2 || left[1].Id != 5 {
+		t.Fatalf("left = %+v", left)
+	}
+}
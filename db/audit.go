@@ -0,0 +1,355 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/restoflife/ql_common/mongo"
+	"go.uber.org/zap"
+	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
+)
+
+// 审计操作类型
+const (
+	AuditOpInsert = "insert"
+	AuditOpUpdate = "update"
+	AuditOpDelete = "delete"
+)
+
+// Auditable 需要记录变更审计的模型实现该接口，返回 true 时才会写入审计记录
+// 审计只在 InsertWithAudit、UpdateWithAudit、DeleteWithAudit 中生效，直接调用 session.Insert 等方法不会记录：
+// xorm 的 SQL 钩子拿不到模型和变更前的值，Before/After 事件也无法访问会话与 context
+// Encrypted、Blind 字段照常加密写入，审计记录中只以占位值标记变更，不记录明文
+type Auditable interface {
+	Auditable() bool
+}
+
+// AuditChange 单个字段的变更前后值
+type AuditChange struct {
+	Before any `json:"before" bson:"before"`
+	After  any `json:"after" bson:"after"`
+}
+
+// AuditRecord 审计记录
+type AuditRecord struct {
+	Id        int64                  `xorm:"pk autoincr 'id'" json:"id" bson:"-"`
+	Table     string                 `xorm:"varchar(64) index 'table_name'" json:"table" bson:"table"`
+	PK        string                 `xorm:"varchar(128) index 'pk'" json:"pk" bson:"pk"`
+	Operation string                 `xorm:"varchar(16) 'operation'" json:"operation" bson:"operation"`
+	Changes   map[string]AuditChange `xorm:"text json 'changes'" json:"changes" bson:"changes"`
+	Actor     string                 `xorm:"varchar(128) 'actor'" json:"actor" bson:"actor"`
+	CreatedAt time.Time              `xorm:"'created_at'" json:"created_at" bson:"created_at"`
+}
+
+// AuditSink 审计记录的写入目标
+type AuditSink interface {
+	Write(ctx context.Context, session *xorm.Session, record *AuditRecord) error
+}
+
+// 全局审计写入目标，由 SetAuditSink 在启动时设置
+var auditSink AuditSink
+
+// actorKey context 中保存操作人的 key
+type actorKey struct{}
+
+// WithActor 在 context 中设置操作人，审计记录会从中读取
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext 从 context 中获取操作人
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// TableAuditSink 将审计记录写入数据库表，与业务写入使用同一会话（同一事务）
+type TableAuditSink struct {
+	Table string // 审计表名，为空时使用 audit_log
+}
+
+func (s *TableAuditSink) tableName() string {
+	if s.Table == "" {
+		return "audit_log"
+	}
+	return s.Table
+}
+
+// Write 使用当前会话写入审计记录
+func (s *TableAuditSink) Write(_ context.Context, session *xorm.Session, record *AuditRecord) error {
+	_, err := session.Table(s.tableName()).Insert(record)
+	return err
+}
+
+// Sync 同步审计表结构，可在 SetSyncFunc 中调用
+func (s *TableAuditSink) Sync(g *xorm.EngineGroup) error {
+	return g.Table(s.tableName()).Sync(new(AuditRecord))
+}
+
+// MongoAuditSink 将审计记录写入 Mongo 集合
+// 在 Transaction 中写入的记录会在事务提交后才落库，回滚时丢弃；非事务会话中立即写入
+type MongoAuditSink struct {
+	Name       string // mongo 配置名称
+	Database   string // 库名
+	Collection string // 集合名
+}
+
+// 事务中尚未提交的 Mongo 审计记录
+var pendingAudits sync.Map // map[*xorm.Session][]*AuditRecord
+
+// Write 写入审计记录
+func (s *MongoAuditSink) Write(_ context.Context, session *xorm.Session, record *AuditRecord) error {
+	if session.IsInTx() {
+		records, _ := pendingAudits.Load(session)
+		list, _ := records.([]*AuditRecord)
+		pendingAudits.Store(session, append(list, record))
+		return nil
+	}
+	_, err := mongo.InsertOne(s.Name, s.Database, s.Collection, record)
+	return err
+}
+
+// flushAudits 事务提交后写入暂存的审计记录
+func flushAudits(session *xorm.Session) error {
+	records, ok := pendingAudits.LoadAndDelete(session)
+	if !ok {
+		return nil
+	}
+	sink, ok := auditSink.(*MongoAuditSink)
+	if !ok {
+		return nil
+	}
+	list := records.([]*AuditRecord)
+	docs := make([]any, len(list))
+	for i, r := range list {
+		docs[i] = r
+	}
+	_, err := mongo.InsertMany(sink.Name, sink.Database, sink.Collection, docs)
+	return err
+}
+
+// FlushAudits 手动提交事务后写入暂存的 Mongo 审计记录，需在 Commit 之后、Close 之前调用；
+// 使用 Transaction 时会自动调用
+func FlushAudits(session *xorm.Session) error {
+	return flushAudits(session)
+}
+
+// discardAudits 丢弃会话中暂存的审计记录（事务回滚或失败）
+func discardAudits(session *xorm.Session) {
+	pendingAudits.Delete(session)
+}
+
+// dropAudits 会话关闭时丢弃暂存的审计记录
+// 会话已不在事务中却仍有暂存记录，说明手动提交后未调用 FlushAudits，记录告警
+func dropAudits(session *xorm.Session) {
+	records, ok := pendingAudits.LoadAndDelete(session)
+	if !ok || session.IsInTx() || sqlLogger == nil {
+		return
+	}
+	sqlLogger.Warn("会话关闭时丢弃了未写入的审计记录，手动提交事务后需调用 FlushAudits",
+		zap.Int("count", len(records.([]*AuditRecord))),
+	)
+}
+
+// InsertWithAudit 插入记录，模型可审计时写入审计记录
+func InsertWithAudit(ctx context.Context, session *xorm.Session, bean any) (int64, error) {
+	affected, err := session.Insert(bean)
+	if err != nil || !needAudit(bean) {
+		return affected, err
+	}
+
+	table, err := session.Engine().TableInfo(bean)
+	if err != nil {
+		return affected, err
+	}
+	changes := make(map[string]AuditChange, len(table.Columns()))
+	for _, col := range table.Columns() {
+		v, err := columnValue(col, bean)
+		if err != nil {
+			return affected, err
+		}
+		changes[col.Name] = AuditChange{After: maskValue(v)}
+	}
+	return affected, writeAudit(ctx, session, table, AuditOpInsert, bean, changes)
+}
+
+// UpdateWithAudit 根据主键更新记录，模型可审计时记录变更前后的字段值
+// cols 为空时遵循 xorm 默认规则（仅更新非零值字段）；审计函数均按主键操作，请勿在会话上预先设置查询条件
+func UpdateWithAudit(ctx context.Context, session *xorm.Session, bean any, cols ...string) (int64, error) {
+	table, err := session.Engine().TableInfo(bean)
+	if err != nil {
+		return 0, err
+	}
+	pk, err := table.IDOfV(reflect.ValueOf(bean))
+	if err != nil {
+		return 0, err
+	}
+
+	audit := needAudit(bean)
+	var before any
+	if audit {
+		if before, err = loadByPK(session, bean, pk); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(cols) > 0 {
+		session.Cols(cols...)
+	}
+	affected, err := session.ID(pk).Update(bean)
+	if err != nil || !audit || affected == 0 {
+		return affected, err
+	}
+
+	// 重新读取更新后的数据，保证记录的是数据库中的真实值
+	after, err := loadByPK(session, bean, pk)
+	if err != nil {
+		return affected, err
+	}
+	changes, err := diffColumns(table, before, after)
+	if err != nil || len(changes) == 0 {
+		return affected, err
+	}
+	return affected, writeAudit(ctx, session, table, AuditOpUpdate, bean, changes)
+}
+
+// DeleteWithAudit 根据主键删除记录，模型可审计时记录删除前的字段值
+func DeleteWithAudit(ctx context.Context, session *xorm.Session, bean any) (int64, error) {
+	table, err := session.Engine().TableInfo(bean)
+	if err != nil {
+		return 0, err
+	}
+	pk, err := table.IDOfV(reflect.ValueOf(bean))
+	if err != nil {
+		return 0, err
+	}
+
+	audit := needAudit(bean)
+	var before any
+	if audit {
+		if before, err = loadByPK(session, bean, pk); err != nil {
+			return 0, err
+		}
+	}
+
+	affected, err := session.ID(pk).NoAutoCondition().Delete(bean)
+	if err != nil || !audit || affected == 0 {
+		return affected, err
+	}
+
+	changes, err := diffColumns(table, before, nil)
+	if err != nil {
+		return affected, err
+	}
+	return affected, writeAudit(ctx, session, table, AuditOpDelete, bean, changes)
+}
+
+// needAudit 判断是否需要写入审计记录
+func needAudit(bean any) bool {
+	if auditSink == nil {
+		return false
+	}
+	a, ok := bean.(Auditable)
+	return ok && a.Auditable()
+}
+
+// writeAudit 组装审计记录并写入
+func writeAudit(ctx context.Context, session *xorm.Session, table *schemas.Table, op string, bean any, changes map[string]AuditChange) error {
+	pk, err := table.IDOfV(reflect.ValueOf(bean))
+	if err != nil {
+		return err
+	}
+	pkStr := make([]string, len(pk))
+	for i, v := range pk {
+		pkStr[i] = fmt.Sprint(v)
+	}
+
+	return auditSink.Write(ctx, session, &AuditRecord{
+		Table:     session.Engine().TableName(bean),
+		PK:        strings.Join(pkStr, ","),
+		Operation: op,
+		Changes:   changes,
+		Actor:     ActorFromContext(ctx),
+		CreatedAt: time.Now(),
+	})
+}
+
+// loadByPK 按主键读取一条记录（包含软删除的记录）
+func loadByPK(session *xorm.Session, bean any, pk schemas.PK) (any, error) {
+	row := reflect.New(reflect.Indirect(reflect.ValueOf(bean)).Type()).Interface()
+	has, err := session.ID(pk).Unscoped().NoAutoCondition().Get(row)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, nil
+	}
+	return row, nil
+}
+
+// diffColumns 比较前后两条记录，返回发生变化的字段
+func diffColumns(table *schemas.Table, before, after any) (map[string]AuditChange, error) {
+	changes := make(map[string]AuditChange)
+	for _, col := range table.Columns() {
+		var b, a any
+		var err error
+		if before != nil {
+			if b, err = columnValue(col, before); err != nil {
+				return nil, err
+			}
+		}
+		if after != nil {
+			if a, err = columnValue(col, after); err != nil {
+				return nil, err
+			}
+		}
+		if !valueEqual(b, a) {
+			changes[col.Name] = AuditChange{Before: maskValue(b), After: maskValue(a)}
+		}
+	}
+	return changes, nil
+}
+
+// columnValue 读取字段值
+func columnValue(col *schemas.Column, bean any) (any, error) {
+	v, err := col.ValueOf(bean)
+	if err != nil {
+		return nil, err
+	}
+	return v.Interface(), nil
+}
+
+// auditMask 加密字段与盲索引在审计记录中的占位值
+const auditMask = "******"
+
+// maskValue 加密字段与盲索引不记录明文或索引值，只记录是否有值
+func maskValue(v any) any {
+	switch val := v.(type) {
+	case Encrypted:
+		if val == "" {
+			return ""
+		}
+		return auditMask
+	case Blind:
+		if val == (Blind{}) {
+			return ""
+		}
+		return auditMask
+	}
+	return v
+}
+
+// valueEqual 比较字段值是否相等，时间类型按时刻比较
+func valueEqual(a, b any) bool {
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			return ta.Equal(tb)
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
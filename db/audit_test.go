@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"xorm.io/xorm"
+)
+
+type auditAccount struct {
+	Id         int64
+	Name       string    `xorm:"varchar(64)"`
+	IdCard     Encrypted `xorm:"varchar(255)"`
+	IdCardHash Blind     `xorm:"varchar(64)"`
+}
+
+func (auditAccount) Auditable() bool { return true }
+
+func TestAudit(t *testing.T) {
+	engine, err := xorm.NewEngine("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Close()
+	engine.SetMaxOpenConns(1)
+	sink := &TableAuditSink{}
+	if err = engine.Sync(new(auditAccount)); err != nil {
+		t.Fatal(err)
+	}
+	if err = engine.Table(sink.tableName()).Sync(new(AuditRecord)); err != nil {
+		t.Fatal(err)
+	}
+	setTestKeys(t, "v1")
+	auditSink = sink
+	defer func() { auditSink = nil }()
+
+	ctx := WithActor(context.Background(), "alice")
+	session := engine.NewSession()
+	defer session.Close()
+
+	acc := &auditAccount{Name: "a", IdCard: "110101199001011234", IdCardHash: BlindOf("110101199001011234")}
+	if _, err = InsertWithAudit(ctx, session, acc); err != nil {
+		t.Fatal(err)
+	}
+	// 审计写入同样经过加密转换
+	raw, err := engine.QueryString("SELECT id_card FROM audit_account")
+	if err != nil || len(raw) != 1 || raw[0]["id_card"] == "110101199001011234" {
+		t.Fatalf("stored id_card = %v, %v", raw, err)
+	}
+
+	acc.Name, acc.IdCard = "b", "110101199001015678"
+	if _, err = UpdateWithAudit(ctx, session, acc, "name", "id_card"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = DeleteWithAudit(ctx, session, acc); err != nil {
+		t.Fatal(err)
+	}
+
+	var records []AuditRecord
+	if err = engine.Table(sink.tableName()).OrderBy("id").Find(&records); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("records = %+v", records)
+	}
+	for i, op := range []string{AuditOpInsert, AuditOpUpdate, AuditOpDelete} {
+		r := records[i]
+		if r.Operation != op || r.Table != "audit_account" || r.PK != "1" || r.Actor != "alice" {
+			t.Fatalf("record %d = %+v", i, r)
+		}
+		// 加密字段与盲索引只记录占位值
+		for _, col := range []string{"id_card", "id_card_hash"} {
+			c, ok := r.Changes[col]
+			if !ok && op == AuditOpUpdate && col == "id_card_hash" {
+				continue
+			}
+			if !ok || (c.Before != nil && c.Before != auditMask) || (c.After != nil && c.After != auditMask) {
+				t.Fatalf("record %d %s = %+v", i, col, c)
+			}
+		}
+	}
+	update := records[1].Changes
+	if len(update) != 2 || update["name"].Before != "a" || update["name"].After != "b" {
+		t.Fatalf("update changes = %+v", update)
+	}
+	if records[2].Changes["name"].Before != "b" || records[2].Changes["name"].After != nil {
+		t.Fatalf("delete changes = %+v", records[2].Changes)
+	}
+}
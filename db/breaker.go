@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/restoflife/ql_common/breaker"
+	"go.uber.org/zap"
+	"xorm.io/xorm/contexts"
+)
+
+// ErrCircuitOpen 数据库熔断器打开时返回的错误
+var ErrCircuitOpen = breaker.ErrCircuitOpen
+
+// 存储每个数据库的熔断器（未配置时不存在）
+var breakerMgr = map[string]*breaker.Breaker{}
+
+// BreakerState 返回数据库熔断器的当前状态，未配置熔断器时返回 false
+func BreakerState(name string) (breaker.State, bool) {
+	lifecycleMu.RLock()
+	b, ok := breakerMgr[name]
+	lifecycleMu.RUnlock()
+	if !ok {
+		return breaker.StateClosed, false
+	}
+	return b.State(), true
+}
+
+// newBreaker 创建数据库熔断器，状态变化记录到 SQL 日志
+func newBreaker(name string, c *breaker.Config) *breaker.Breaker {
+	return breaker.New(name, c, func(name string, from, to breaker.State) {
+		if sqlLogger == nil {
+			return
+		}
+		sqlLogger.Warn("数据库熔断器状态变化",
+			zap.String("name", name),
+			zap.String("from", from.String()),
+			zap.String("to", to.String()),
+		)
+	})
+}
+
+// checkBreaker 熔断器打开时快速失败，调用方需持有 lifecycleMu
+func checkBreaker(name string) error {
+	if b, ok := breakerMgr[name]; ok {
+		return b.Check()
+	}
+	return nil
+}
+
+// breakerGenerationKey context 中保存熔断器统计周期的 key
+type breakerGenerationKey struct{}
+
+// breakerHook 在每条 SQL 执行前后更新熔断器
+type breakerHook struct {
+	breaker *breaker.Breaker
+}
+
+func (h *breakerHook) BeforeProcess(c *contexts.ContextHook) (context.Context, error) {
+	generation, err := h.breaker.Allow()
+	if err != nil {
+		return nil, err
+	}
+	// 后续钩子在此基础上派生 context，AfterProcess 才能取到统计周期
+	c.Ctx = context.WithValue(c.Ctx, breakerGenerationKey{}, generation)
+	return c.Ctx, nil
+}
+
+func (h *breakerHook) AfterProcess(c *contexts.ContextHook) error {
+	if generation, ok := c.Ctx.Value(breakerGenerationKey{}).(uint64); ok {
+		h.breaker.Record(generation, !isBreakerFailure(c.Err))
+	}
+	return nil
+}
+
+// isBreakerFailure 判断错误是否代表数据库不可用：连接错误、超时和服务端故障，
+// 其他错误（sql.ErrNoRows、调用方取消、约束冲突、参数校验等）不计入失败
+func isBreakerFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var ne net.Error
+	if errors.As(err, &ne) {
+		return true
+	}
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		switch me.Number {
+		case 1040, // 连接数耗尽
+			1203, // 用户连接数耗尽
+			1053, // 服务端正在关闭
+			1927, // 连接被终止
+			3024: // 超过 max_execution_time
+			return true
+		}
+	}
+	return false
+}
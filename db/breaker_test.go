@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/restoflife/ql_common/breaker"
+	"xorm.io/xorm"
+)
+
+func TestIsBreakerFailure(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{sql.ErrNoRows, false},
+		{context.Canceled, false},
+		{errors.New("invalid argument"), false},
+		{&mysql.MySQLError{Number: 1062}, false},
+		{context.DeadlineExceeded, true},
+		{&TimeoutError{Err: context.DeadlineExceeded}, true},
+		{fmt.Errorf("query: %w", driver.ErrBadConn), true},
+		{mysql.ErrInvalidConn, true},
+		{&mysql.MySQLError{Number: 1040}, true},
+	}
+	for _, tt := range tests {
+		if got := isBreakerFailure(tt.err); got != tt.want {
+			t.Errorf("isBreakerFailure(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestBreakerHookRecords(t *testing.T) {
+	engine, err := xorm.NewEngine("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine.SetMaxOpenConns(1)
+	g, err := xorm.NewEngineGroup(engine, []*xorm.Engine{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = Register("breaker_test", g, &XORMConfigLite{Breaker: &breaker.Config{ConsecutiveFailures: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	defer Unregister("breaker_test")
+
+	// 其他钩子之后执行的 AfterProcess 仍能取到统计周期并记录结果
+	if _, err = g.QueryString("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	if counts := breakerMgr["breaker_test"].Counts(); counts.Requests != 1 || counts.Successes != 1 {
+		t.Fatalf("counts = %+v", counts)
+	}
+}
@@ -0,0 +1,275 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/restoflife/ql_common/redis"
+	"go.uber.org/zap"
+	"xorm.io/xorm"
+	"xorm.io/xorm/caches"
+)
+
+// DefaultCacheTTL 查询缓存的默认过期时间
+const DefaultCacheTTL = 10 * time.Minute
+
+// 缓存读写的最长耗时，超时按未命中处理
+const cacheTimeout = time.Second
+
+// CacheConfig 查询缓存配置，缓存写在 redis 包中的命名实例（需先启动 Redis）
+// xorm 仅缓存单主键表的 Get/Find 结果，通过同一引擎写入时会自动失效对应表的缓存
+type CacheConfig struct {
+	Redis  string         `toml:"redis" yaml:"redis" json:"redis"`    // Redis 实例名称
+	Prefix string         `toml:"prefix" yaml:"prefix" json:"prefix"` // 缓存 key 前缀，为空时使用 xorm:<数据库名称>
+	TTL    int            `toml:"ttl" yaml:"ttl" json:"ttl"`          // 默认过期时间（单位：毫秒），0 使用默认 10 分钟
+	Tables map[string]int `toml:"tables" yaml:"tables" json:"tables"` // 按表设置过期时间（单位：毫秒，0 使用 ttl）；为空时缓存所有表，否则只缓存列出的表
+}
+
+// setupCache 按配置为引擎组设置查询缓存
+func setupCache(name string, g *xorm.EngineGroup, c *CacheConfig) error {
+	// 缓存读写都访问主节点，避免读到从节点上已失效的数据
+	client, err := redis.GetMaster(c.Redis)
+	if err != nil {
+		return err
+	}
+	prefix := c.Prefix
+	if prefix == "" {
+		prefix = "xorm:" + name
+	}
+	ttl := DefaultCacheTTL
+	if c.TTL > 0 {
+		ttl = time.Millisecond * time.Duration(c.TTL)
+	}
+
+	if len(c.Tables) == 0 {
+		g.SetDefaultCacher(NewRedisCacher(client, prefix, ttl))
+		return nil
+	}
+	engines := append([]*xorm.Engine{g.Master()}, g.Slaves()...)
+	for table, ms := range c.Tables {
+		t := ttl
+		if ms > 0 {
+			t = time.Millisecond * time.Duration(ms)
+		}
+		// 主从共用同一个缓存实例，主库写入时可使从库读到的缓存失效
+		cacher := NewRedisCacher(client, prefix, t)
+		for _, e := range engines {
+			e.SetCacher(table, cacher)
+		}
+	}
+	return nil
+}
+
+// RedisCacher 基于 Redis 的 xorm 缓存实现（caches.Cacher）
+// key 格式：<prefix>:{<表名>}:bean:<主键> 与 <prefix>:{<表名>}:sql:<SQL 摘要>，
+// 同一张表的 key 使用相同的 hash tag，集群模式下位于同一槽位，便于整表清理
+type RedisCacher struct {
+	client goredis.UniversalClient
+	prefix string
+	ttl    time.Duration
+}
+
+// NewRedisCacher 创建 Redis 缓存
+func NewRedisCacher(client goredis.UniversalClient, prefix string, ttl time.Duration) *RedisCacher {
+	return &RedisCacher{client: client, prefix: prefix, ttl: ttl}
+}
+
+var _ caches.Cacher = (*RedisCacher)(nil)
+
+// 已注册到 gob 的模型类型
+var gobTypes sync.Map // map[reflect.Type]struct{}
+
+func (c *RedisCacher) GetIds(tableName, sql string) any {
+	data, ok := c.get(c.sqlKey(tableName, sql))
+	if !ok {
+		return nil
+	}
+	return string(data)
+}
+
+func (c *RedisCacher) GetBean(tableName string, id string) any {
+	data, ok := c.get(c.beanKey(tableName, id))
+	if !ok {
+		return nil
+	}
+	var bean any
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&bean); err != nil {
+		// 类型尚未注册（进程重启后首次读取）或结构已变化，按未命中处理
+		return nil
+	}
+	return bean
+}
+
+func (c *RedisCacher) PutIds(tableName, sql string, ids any) {
+	s, ok := ids.(string)
+	if !ok {
+		return
+	}
+	c.put(tableName, c.sqlKey(tableName, sql), c.sqlSet(tableName), []byte(s))
+}
+
+func (c *RedisCacher) PutBean(tableName string, id string, obj any) {
+	if err := registerGob(obj); err != nil {
+		c.logError("register", tableName, err)
+		return
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&obj); err != nil {
+		c.logError("encode", tableName, err)
+		return
+	}
+	c.put(tableName, c.beanKey(tableName, id), c.beanSet(tableName), buf.Bytes())
+}
+
+// registerGob 以包含包路径的名称注册模型类型，名称冲突时返回错误而不是 panic
+func registerGob(obj any) (err error) {
+	t := reflect.TypeOf(obj)
+	if t == nil {
+		return nil
+	}
+	if _, ok := gobTypes.Load(t); ok {
+		return nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("gob register %s: %v", t, r)
+		}
+	}()
+
+	name, elem := "", t
+	for elem.Kind() == reflect.Pointer {
+		name += "*"
+		elem = elem.Elem()
+	}
+	if elem.Name() == "" || elem.PkgPath() == "" {
+		name = t.String()
+	} else {
+		name += elem.PkgPath() + "." + elem.Name()
+	}
+	gob.RegisterName(name, obj)
+	gobTypes.Store(t, struct{}{})
+	return nil
+}
+
+func (c *RedisCacher) DelIds(tableName, sql string) {
+	c.del(tableName, c.sqlSet(tableName), c.sqlKey(tableName, sql))
+}
+
+func (c *RedisCacher) DelBean(tableName string, id string) {
+	c.del(tableName, c.beanSet(tableName), c.beanKey(tableName, id))
+}
+
+func (c *RedisCacher) ClearIds(tableName string) {
+	c.clear(tableName, c.sqlSet(tableName))
+}
+
+func (c *RedisCacher) ClearBeans(tableName string) {
+	c.clear(tableName, c.beanSet(tableName))
+}
+
+// key 生成
+
+func (c *RedisCacher) tablePrefix(tableName string) string {
+	return c.prefix + ":{" + tableName + "}:"
+}
+
+func (c *RedisCacher) beanKey(tableName, id string) string {
+	return c.tablePrefix(tableName) + "bean:" + id
+}
+
+func (c *RedisCacher) sqlKey(tableName, sql string) string {
+	sum := sha1.Sum([]byte(sql))
+	return c.tablePrefix(tableName) + "sql:" + hex.EncodeToString(sum[:])
+}
+
+// beanSet 记录表中已缓存的 bean key，用于 ClearBeans
+func (c *RedisCacher) beanSet(tableName string) string {
+	return c.tablePrefix(tableName) + "beans"
+}
+
+// sqlSet 记录表中已缓存的 SQL key，用于 ClearIds
+func (c *RedisCacher) sqlSet(tableName string) string {
+	return c.tablePrefix(tableName) + "sqls"
+}
+
+// Redis 操作
+
+func (c *RedisCacher) get(key string) ([]byte, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), cacheTimeout)
+	defer cancel()
+	data, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if !errors.Is(err, goredis.Nil) {
+			c.logError("get", key, err)
+		}
+		return nil, false
+	}
+	return data, true
+}
+
+func (c *RedisCacher) put(tableName, key, set string, data []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), cacheTimeout)
+	defer cancel()
+	_, err := c.client.TxPipelined(ctx, func(p goredis.Pipeliner) error {
+		p.Set(ctx, key, data, c.ttl)
+		p.SAdd(ctx, set, key)
+		// 记录集合比缓存多保留一个周期，保证清理时能覆盖所有未过期的 key
+		p.Expire(ctx, set, 2*c.ttl)
+		return nil
+	})
+	if err != nil {
+		c.logError("put", tableName, err)
+	}
+}
+
+func (c *RedisCacher) del(tableName, set, key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), cacheTimeout)
+	defer cancel()
+	_, err := c.client.TxPipelined(ctx, func(p goredis.Pipeliner) error {
+		p.Del(ctx, key)
+		p.SRem(ctx, set, key)
+		return nil
+	})
+	if err != nil {
+		c.logError("del", tableName, err)
+	}
+}
+
+// clearScript 删除集合中记录的所有 key 及集合本身
+var clearScript = goredis.NewScript(`
+local keys = redis.call('SMEMBERS', KEYS[1])
+for i = 1, #keys, 500 do
+	redis.call('DEL', unpack(keys, i, math.min(i + 499, #keys)))
+end
+redis.call('DEL', KEYS[1])
+return #keys
+`)
+
+func (c *RedisCacher) clear(tableName, set string) {
+	ctx, cancel := context.WithTimeout(context.Background(), cacheTimeout)
+	defer cancel()
+	if err := clearScript.Run(ctx, c.client, []string{set}).Err(); err != nil && !errors.Is(err, goredis.Nil) {
+		c.logError("clear", tableName, err)
+	}
+}
+
+// logError 缓存失败只记录日志，不影响查询
+func (c *RedisCacher) logError(op, target string, err error) {
+	if sqlLogger == nil {
+		return
+	}
+	sqlLogger.Warn("XORM缓存操作失败",
+		zap.String("op", op),
+		zap.String("target", target),
+		zap.Error(err),
+	)
+}
@@ -0,0 +1,25 @@
+package db
+
+import (
+	"encoding/gob"
+	"testing"
+)
+
+type gobModel struct{ Id int64 }
+
+type gobOther struct{ Name string }
+
+func TestRegisterGobConflict(t *testing.T) {
+	if err := registerGob(&gobModel{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := registerGob(&gobModel{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 同名的其他类型已注册时返回错误而不是 panic
+	gob.RegisterName("*github.com/restoflife/ql_common/db.gobOther", struct{ X int }{})
+	if err := registerGob(&gobOther{}); err == nil {
+		t.Fatal("expected name conflict error")
+	}
+}
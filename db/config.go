@@ -1,5 +1,7 @@
 package db
 
+import "github.com/restoflife/ql_common/breaker"
+
 type XORMConfigLite struct {
 	Driver  string `toml:"driver" yaml:"driver" json:"driver"`       // 数据库驱动类型，例如：mysql、postgres、sqlite3
 	Dsn     string `toml:"dsn" yaml:"dsn" json:"dsn"`                // 主数据库 DSN（数据源名称），如：user:pass@tcp(127.0.0.1:3306)/dbname
@@ -13,4 +15,12 @@ type XORMConfigLite struct {
 
 	MaxLife         int  `toml:"max_life" yaml:"max_life" json:"max_life"`                      // 连接的最大生命周期（单位：秒），超时将重连
 	Synchronization bool `toml:"synchronization" yaml:"synchronization" json:"synchronization"` // 是否自动同步数据库结构（建表、更新字段）
+
+	StatementTimeout int `toml:"statement_timeout" yaml:"statement_timeout" json:"statement_timeout"` // 单条 SQL 默认超时（单位：毫秒），仅对 context 未设置截止时间的会话生效，0 表示不限制
+	TxTimeout        int `toml:"tx_timeout" yaml:"tx_timeout" json:"tx_timeout"`                      // 事务默认超时（单位：毫秒），0 时使用 statement_timeout
+
+	DiagnoseInterval int `toml:"diagnose_interval" yaml:"diagnose_interval" json:"diagnose_interval"` // 死锁/锁等待诊断信息采集的最小间隔（单位：毫秒），0 使用默认 1 分钟，小于 0 不采集（仅 mysql）
+
+	Breaker *breaker.Config `toml:"breaker" yaml:"breaker" json:"breaker"` // 熔断器配置（可选），为空时不启用
+	Cache   *CacheConfig    `toml:"cache" yaml:"cache" json:"cache"`       // 查询缓存配置（可选），为空时不启用
 }
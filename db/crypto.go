@@ -0,0 +1,185 @@
+package db
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 加密字段存储格式：enc:<密钥ID>:<base64(nonce+密文)>
+const cipherPrefix = "enc:"
+
+// ErrNoKeyProvider 未配置密钥提供者
+var ErrNoKeyProvider = errors.New("crypto key provider is not configured")
+
+// 全局密钥提供者，由 SetKeyProvider 在启动时设置
+var keyProvider KeyProvider
+
+// Encrypted 加密字段类型：xorm 写入时以当前密钥 AES-GCM 加密，读取时按密文中的密钥 ID 解密，
+// Insert/Update/Get/Find/Rows 等所有读写路径均生效；加密失败时写入中止，空字符串不加密，库中的明文（迁移前的数据）原样读出
+// 写入时 bean 需传指针，字段不可寻址时 xorm 不会调用转换
+//
+//	type User struct {
+//		Id         int64
+//		IdCard     db.Encrypted `xorm:"varchar(255)"`
+//		IdCardHash db.Blind     `xorm:"varchar(64) index"`
+//	}
+//	user := &User{IdCard: db.Encrypted(idCard), IdCardHash: db.BlindOf(idCard)}
+//	_, err := session.Insert(user)
+//	has, err := session.Where("id_card_hash = ?", db.BlindOf(idCard)).Get(user)
+type Encrypted string
+
+// ToDB 实现 convert.Conversion，写入前加密
+func (e Encrypted) ToDB() ([]byte, error) {
+	if e == "" {
+		return []byte{}, nil
+	}
+	enc, err := Encrypt(string(e))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(enc), nil
+}
+
+// FromDB 实现 convert.Conversion，读取后解密
+func (e *Encrypted) FromDB(data []byte) error {
+	plain, err := Decrypt(string(data))
+	if err != nil {
+		return err
+	}
+	*e = Encrypted(plain)
+	return nil
+}
+
+// Blind 盲索引字段类型，库中保存明文的 HMAC-SHA256，用于加密字段的等值查询
+// 通过 BlindOf 赋值，写入或作为查询参数时计算索引；从库中读出的是索引本身，再次写入时保持不变
+type Blind struct {
+	plain string // BlindOf 设置的明文
+	index string // 从库中读出的索引
+	set   bool   // 是否由 BlindOf 设置
+}
+
+// BlindOf 返回明文对应的盲索引字段值
+func BlindOf(plain string) Blind {
+	return Blind{plain: plain, set: true}
+}
+
+// ToDB 实现 convert.Conversion，返回盲索引
+func (b Blind) ToDB() ([]byte, error) {
+	idx, err := b.Index()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(idx), nil
+}
+
+// FromDB 实现 convert.Conversion
+func (b *Blind) FromDB(data []byte) error {
+	*b = Blind{index: string(data)}
+	return nil
+}
+
+// Value 实现 driver.Valuer，使 Blind 可直接作为查询条件的参数
+func (b Blind) Value() (driver.Value, error) {
+	return b.Index()
+}
+
+// Index 返回盲索引，空明文对应空字符串
+func (b Blind) Index() (string, error) {
+	if !b.set {
+		return b.index, nil
+	}
+	if b.plain == "" {
+		return "", nil
+	}
+	return BlindIndex(b.plain)
+}
+
+// Encrypt 使用当前密钥以 AES-GCM 加密
+func Encrypt(plain string) (string, error) {
+	if keyProvider == nil {
+		return "", ErrNoKeyProvider
+	}
+	id, key, err := keyProvider.CurrentKey()
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), []byte(id))
+	return cipherPrefix + id + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密 Encrypt 的结果，根据密文中的密钥 ID 选择密钥；非密文原样返回
+func Decrypt(value string) (string, error) {
+	if !strings.HasPrefix(value, cipherPrefix) {
+		return value, nil
+	}
+	if keyProvider == nil {
+		return "", ErrNoKeyProvider
+	}
+	id, data, ok := strings.Cut(strings.TrimPrefix(value, cipherPrefix), ":")
+	if !ok {
+		return "", errors.New("crypto: malformed ciphertext")
+	}
+	key, err := keyProvider.Key(id)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", fmt.Errorf("crypto: malformed ciphertext: %w", err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("crypto: malformed ciphertext")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(id))
+	if err != nil {
+		return "", fmt.Errorf("crypto: decrypt failed: %w", err)
+	}
+	return string(plain), nil
+}
+
+// BlindIndex 计算确定性的盲索引（HMAC-SHA256），用于加密字段的等值查询
+//
+//	idx, _ := db.BlindIndex(idCard)
+//	session.Where("id_card_hash = ?", idx).Get(&user)
+func BlindIndex(plain string) (string, error) {
+	if keyProvider == nil {
+		return "", ErrNoKeyProvider
+	}
+	key, err := keyProvider.BlindKey()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(plain))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// newGCM 创建 AES-GCM
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
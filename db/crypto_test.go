@@ -0,0 +1,112 @@
+package db
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+	"xorm.io/xorm"
+)
+
+type cryptoUser struct {
+	Id         int64
+	IdCard     Encrypted `xorm:"varchar(255)"`
+	IdCardHash Blind     `xorm:"varchar(64) index"`
+}
+
+// setTestKeys 使用环境变量密钥提供者，current 为当前密钥 ID
+func setTestKeys(t *testing.T, current string) {
+	t.Helper()
+	t.Setenv("TEST_CRYPTO_KEYS", "v1:MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=,v2:ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=")
+	t.Setenv("TEST_CRYPTO_CURRENT", current)
+	t.Setenv("TEST_CRYPTO_BLIND_KEY", "YmxpbmQta2V5")
+	p, err := NewEnvKeyProvider("TEST_CRYPTO")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyProvider = p
+	t.Cleanup(func() { keyProvider = nil })
+}
+
+func TestEncryptedRotation(t *testing.T) {
+	setTestKeys(t, "v1")
+	old, err := Encrypted("110101199001011234").ToDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(old), "enc:v1:") {
+		t.Fatalf("ciphertext = %q", old)
+	}
+	idx, err := BlindOf("110101199001011234").Index()
+	if err != nil || idx == "" {
+		t.Fatalf("Index = %q, %v", idx, err)
+	}
+
+	// 轮换密钥后历史密文仍可解密，盲索引不变
+	setTestKeys(t, "v2")
+	var plain Encrypted
+	if err = plain.FromDB(old); err != nil || plain != "110101199001011234" {
+		t.Fatalf("FromDB = %q, %v", plain, err)
+	}
+	enc, err := plain.ToDB()
+	if err != nil || !strings.HasPrefix(string(enc), "enc:v2:") {
+		t.Fatalf("ciphertext after rotation = %q, %v", enc, err)
+	}
+	if idx2, _ := BlindOf("110101199001011234").Index(); idx2 != idx {
+		t.Fatalf("blind index changed after rotation: %q != %q", idx2, idx)
+	}
+}
+
+func TestEncryptedColumn(t *testing.T) {
+	engine, err := xorm.NewEngine("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Close()
+	engine.SetMaxOpenConns(1)
+	if err = engine.Sync(new(cryptoUser)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 未配置密钥时加密失败，不会写入明文
+	if _, err = engine.Insert(&cryptoUser{IdCard: "110101199001011234"}); !errors.Is(err, ErrNoKeyProvider) {
+		t.Fatalf("Insert without keys err = %v", err)
+	}
+
+	setTestKeys(t, "v1")
+	u := &cryptoUser{IdCard: "110101199001011234", IdCardHash: BlindOf("110101199001011234")}
+	if _, err = engine.Insert(u); err != nil {
+		t.Fatal(err)
+	}
+
+	// 库中保存的是密文与盲索引
+	raw, err := engine.QueryString("SELECT id_card, id_card_hash FROM crypto_user")
+	if err != nil || len(raw) != 1 {
+		t.Fatalf("QueryString = %v, %v", raw, err)
+	}
+	if !strings.HasPrefix(raw[0]["id_card"], "enc:v1:") || strings.Contains(raw[0]["id_card_hash"], "1234") {
+		t.Fatalf("stored row = %v", raw[0])
+	}
+
+	// 按盲索引查询，读取时自动解密
+	var got cryptoUser
+	has, err := engine.Where("id_card_hash = ?", BlindOf("110101199001011234")).Get(&got)
+	if err != nil || !has || got.IdCard != "110101199001011234" {
+		t.Fatalf("Get = %+v, %v, %v", got, has, err)
+	}
+	var rows []cryptoUser
+	if err = engine.Find(&rows); err != nil || len(rows) != 1 || rows[0].IdCard != "110101199001011234" {
+		t.Fatalf("Find = %+v, %v", rows, err)
+	}
+
+	// 读出的盲索引再次写入时保持不变
+	got.IdCard = "110101199001015678"
+	if _, err = engine.ID(got.Id).AllCols().Update(&got); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := engine.QueryString("SELECT id_card_hash FROM crypto_user")
+	if after[0]["id_card_hash"] != raw[0]["id_card_hash"] {
+		t.Fatalf("blind index rewritten: %q != %q", after[0]["id_card_hash"], raw[0]["id_card_hash"])
+	}
+}
@@ -0,0 +1,151 @@
+// Package dbtest 为使用 db 包的代码提供测试环境：
+// 以生产配置中的名称注册内存 SQLite 数据库，同步模型、加载 YAML/JSON 数据，测试结束后自动清理
+//
+//	func TestOrder(t *testing.T) {
+//		dbtest.Open(t, "default", dbtest.WithModels(new(Order)), dbtest.WithFixtures("testdata/order.yaml"))
+//		ctx := dbtest.Tx(t, context.Background(), "default")
+//		order, err := db.Get[Order](ctx, "default", builder.Eq{"id": 1})
+//		...
+//	}
+package dbtest
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/restoflife/ql_common/db"
+	_ "modernc.org/sqlite"
+	"xorm.io/xorm"
+)
+
+// Driver 测试数据库使用的驱动（modernc.org/sqlite，无需 cgo）
+const Driver = "sqlite"
+
+// 数据库序号，保证每个测试得到独立的内存库
+var seq atomic.Int64
+
+// Options Open 的可选参数
+type Options struct {
+	config   *db.XORMConfigLite
+	models   []any
+	sync     func(name string, g *xorm.EngineGroup) error
+	fixtures []string
+	showSQL  bool
+}
+
+// Option 是对 Options 的函数式配置
+type Option func(*Options)
+
+// WithConfig 使用指定配置注册（如超时、熔断），Driver 与 Dsn 会被忽略
+func WithConfig(c *db.XORMConfigLite) Option {
+	return func(o *Options) {
+		o.config = c
+	}
+}
+
+// WithModels 同步模型的表结构
+func WithModels(beans ...any) Option {
+	return func(o *Options) {
+		o.models = append(o.models, beans...)
+	}
+}
+
+// WithSync 使用与生产相同的同步函数或迁移建表，在 WithModels 之后执行
+func WithSync(fn func(name string, g *xorm.EngineGroup) error) Option {
+	return func(o *Options) {
+		o.sync = fn
+	}
+}
+
+// WithFixtures 建表后加载数据文件，格式见 LoadFixtures
+func WithFixtures(paths ...string) Option {
+	return func(o *Options) {
+		o.fixtures = append(o.fixtures, paths...)
+	}
+}
+
+// WithShowSQL 输出执行的 SQL
+func WithShowSQL() Option {
+	return func(o *Options) {
+		o.showSQL = true
+	}
+}
+
+// Open 以 name 注册一个全新的内存 SQLite 数据库，测试结束时注销
+// 内存库只使用一个连接，同一时间只能有一个会话执行事务；测试中请通过 Tx 返回的 context 访问数据库
+func Open(t testing.TB, name string, opts ...Option) *xorm.EngineGroup {
+	t.Helper()
+	o := &Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	dsn := fmt.Sprintf("file:dbtest_%d?mode=memory", seq.Add(1))
+	engine, err := xorm.NewEngine(Driver, dsn)
+	if err != nil {
+		t.Fatalf("dbtest: open %s: %v", name, err)
+	}
+	// 内存库随连接关闭而销毁，固定为一个长期连接
+	engine.SetMaxOpenConns(1)
+	engine.SetMaxIdleConns(1)
+	engine.SetConnMaxLifetime(0)
+	engine.ShowSQL(o.showSQL)
+
+	g, err := xorm.NewEngineGroup(engine, []*xorm.Engine{})
+	if err != nil {
+		t.Fatalf("dbtest: open %s: %v", name, err)
+	}
+
+	if len(o.models) > 0 {
+		if err = g.Sync(o.models...); err != nil {
+			_ = g.Close()
+			t.Fatalf("dbtest: sync %s: %v", name, err)
+		}
+	}
+	if o.sync != nil {
+		if err = o.sync(name, g); err != nil {
+			_ = g.Close()
+			t.Fatalf("dbtest: sync %s: %v", name, err)
+		}
+	}
+	if err = loadFixtures(g, o.fixtures...); err != nil {
+		_ = g.Close()
+		t.Fatalf("dbtest: %v", err)
+	}
+
+	c := &db.XORMConfigLite{}
+	if o.config != nil {
+		cp := *o.config
+		c = &cp
+	}
+	c.Driver, c.Dsn = Driver, dsn
+	if err = db.Register(name, g, c); err != nil {
+		_ = g.Close()
+		t.Fatalf("dbtest: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Unregister(name)
+	})
+	return g
+}
+
+// Tx 开启一个事务并放入 context，测试结束时回滚
+// 返回的 context 传给 db.Get/Find/Transaction 等函数时会加入该事务，测试之间互不影响
+func Tx(t testing.TB, ctx context.Context, name string) context.Context {
+	t.Helper()
+	session, err := db.NewSessionContext(ctx, name)
+	if err != nil {
+		t.Fatalf("dbtest: %v", err)
+	}
+	if err = session.Begin(); err != nil {
+		db.Close(session)
+		t.Fatalf("dbtest: begin %s: %v", name, err)
+	}
+	t.Cleanup(func() {
+		_ = session.Rollback()
+		db.Close(session)
+	})
+	return db.WithSession(ctx, name, session)
+}
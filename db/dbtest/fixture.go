@@ -0,0 +1,77 @@
+package dbtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"xorm.io/xorm"
+)
+
+// LoadFixtures 向数据库写入数据文件，文件名（不含扩展名）为表名，内容为行列表：
+//
+//	# testdata/order.yaml
+//	- id: 1
+//	  status: paid
+//	- id: 2
+//	  status: closed
+//
+// 支持 .yaml、.yml 与 .json；文件按参数顺序加载
+func LoadFixtures(t testing.TB, g *xorm.EngineGroup, paths ...string) {
+	t.Helper()
+	if err := loadFixtures(g, paths...); err != nil {
+		t.Fatalf("dbtest: %v", err)
+	}
+}
+
+// loadFixtures 在一个事务中加载所有数据文件
+func loadFixtures(g *xorm.EngineGroup, paths ...string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	session := g.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	for _, path := range paths {
+		rows, err := readFixture(path)
+		if err != nil {
+			_ = session.Rollback()
+			return err
+		}
+		table := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		for i, row := range rows {
+			if _, err = session.Table(table).Insert(row); err != nil {
+				_ = session.Rollback()
+				return fmt.Errorf("fixture %s row %d: %w", path, i, err)
+			}
+		}
+	}
+	return session.Commit()
+}
+
+// readFixture 解析数据文件
+func readFixture(path string) ([]map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rows []map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &rows)
+	case ".json":
+		err = json.Unmarshal(data, &rows)
+	default:
+		return nil, fmt.Errorf("fixture %s: unsupported format", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fixture %s: %w", path, err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,190 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/restoflife/ql_common/logger"
+	"go.uber.org/zap"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+	"xorm.io/xorm/contexts"
+)
+
+// DefaultDiagnoseInterval 同一数据库两次采集锁诊断信息的默认最小间隔
+const DefaultDiagnoseInterval = time.Minute
+
+// MySQL 锁相关错误码
+const (
+	mysqlLockWaitTimeout = 1205 // Lock wait timeout exceeded
+	mysqlDeadlock        = 1213 // Deadlock found when trying to get lock
+)
+
+// 采集诊断信息的最长耗时
+const diagnoseTimeout = 5 * time.Second
+
+// 当前锁等待及阻塞方事务（MySQL 8.0+）
+const lockWaitsSQL = `SELECT r.trx_id AS waiting_trx_id, r.trx_mysql_thread_id AS waiting_thread, r.trx_query AS waiting_query,
+	b.trx_id AS blocking_trx_id, b.trx_mysql_thread_id AS blocking_thread, b.trx_query AS blocking_query, b.trx_started AS blocking_started
+FROM performance_schema.data_lock_waits w
+JOIN information_schema.innodb_trx b ON b.trx_id = w.BLOCKING_ENGINE_TRANSACTION_ID
+JOIN information_schema.innodb_trx r ON r.trx_id = w.REQUESTING_ENGINE_TRANSACTION_ID`
+
+// 持有锁时间最长的事务
+const longTrxSQL = `SELECT trx_id, trx_mysql_thread_id, trx_state, trx_started, trx_rows_locked, trx_query
+FROM information_schema.innodb_trx ORDER BY trx_started LIMIT 20`
+
+// diagnoseInterval 锁诊断采集间隔，小于 0 时不采集
+func (c *XORMConfigLite) diagnoseInterval() time.Duration {
+	if c.DiagnoseInterval == 0 {
+		return DefaultDiagnoseInterval
+	}
+	return time.Millisecond * time.Duration(c.DiagnoseInterval)
+}
+
+// lockErrorCode 判断是否为死锁或锁等待超时错误
+func lockErrorCode(err error) (uint16, bool) {
+	var me *mysql.MySQLError
+	if errors.As(err, &me) && (me.Number == mysqlDeadlock || me.Number == mysqlLockWaitTimeout) {
+		return me.Number, true
+	}
+	return 0, false
+}
+
+// diagnoseHook SQL 因死锁或锁等待超时失败时采集 InnoDB 诊断信息，并与失败的 SQL、请求字段一起记录
+type diagnoseHook struct {
+	name     string
+	engine   *xorm.Engine
+	interval time.Duration
+	last     atomic.Int64 // 上次采集时间（UnixNano）
+}
+
+func (h *diagnoseHook) BeforeProcess(c *contexts.ContextHook) (context.Context, error) {
+	return c.Ctx, nil
+}
+
+func (h *diagnoseHook) AfterProcess(c *contexts.ContextHook) error {
+	code, ok := lockErrorCode(c.Err)
+	if !ok || sqlLogger == nil {
+		return nil
+	}
+
+	fields := []zap.Field{
+		zap.String("name", h.name),
+		zap.Uint16("code", code),
+		zap.String("sql", boundSQL(c.SQL, c.Args)),
+		zap.String("latency", c.ExecuteTime.String()),
+		zap.Error(c.Err),
+	}
+	if actor := ActorFromContext(c.Ctx); actor != "" {
+		fields = append(fields, zap.String("actor", actor))
+	}
+	fields = append(fields, logger.FieldsFromContext(c.Ctx)...)
+
+	// 限频：间隔内只记录错误，不重复采集
+	now := time.Now().UnixNano()
+	last := h.last.Load()
+	if now-last < int64(h.interval) || !h.last.CompareAndSwap(last, now) {
+		sqlLogger.Warn("SQL锁冲突", fields...)
+		return nil
+	}
+
+	// 异步采集，不增加失败请求的耗时
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), diagnoseTimeout)
+		defer cancel()
+		var diag string
+		var err error
+		if code == mysqlDeadlock {
+			diag, err = h.latestDeadlock(ctx)
+		} else {
+			diag, err = h.lockWaits(ctx)
+		}
+		if err != nil {
+			fields = append(fields, zap.NamedError("diagnose_error", err))
+		}
+		sqlLogger.Error("SQL锁冲突诊断", append(fields, zap.String("diagnostics", diag))...)
+	}()
+	return nil
+}
+
+// latestDeadlock 读取 SHOW ENGINE INNODB STATUS 中的 LATEST DETECTED DEADLOCK 部分（需要 PROCESS 权限）
+func (h *diagnoseHook) latestDeadlock(ctx context.Context) (string, error) {
+	rows, err := h.engine.Context(ctx).QueryString("SHOW ENGINE INNODB STATUS")
+	if err != nil {
+		return "", err
+	}
+	if len(rows) == 0 {
+		return "", nil
+	}
+	return deadlockSection(rows[0]["Status"]), nil
+}
+
+// lockWaits 读取当前的锁等待与长事务
+func (h *diagnoseHook) lockWaits(ctx context.Context) (string, error) {
+	var b strings.Builder
+	waits, err := h.engine.Context(ctx).QueryString(lockWaitsSQL)
+	if err != nil {
+		return "", err
+	}
+	b.WriteString("LOCK WAITS\n")
+	writeRows(&b, waits)
+
+	trx, err := h.engine.Context(ctx).QueryString(longTrxSQL)
+	if err != nil {
+		return b.String(), err
+	}
+	b.WriteString("TRANSACTIONS\n")
+	writeRows(&b, trx)
+	return b.String(), nil
+}
+
+// deadlockSection 从 InnoDB 状态中截取 LATEST DETECTED DEADLOCK 部分
+func deadlockSection(status string) string {
+	const title = "LATEST DETECTED DEADLOCK"
+	start := strings.Index(status, title)
+	if start < 0 {
+		return ""
+	}
+	section := status[start:]
+	// 跳过标题下方的分隔线，截取到下一个分隔线（下一部分的标题）之前
+	if i := strings.Index(section, "\n"); i >= 0 {
+		if j := strings.Index(section[i+1:], "\n"); j >= 0 {
+			body := section[i+1+j+1:]
+			if k := strings.Index(body, "\n------------"); k >= 0 {
+				body = body[:k]
+			}
+			return title + "\n" + strings.TrimSpace(body)
+		}
+	}
+	return section
+}
+
+// writeRows 按行输出查询结果
+func writeRows(b *strings.Builder, rows []map[string]string) {
+	for _, row := range rows {
+		first := true
+		for k, v := range row {
+			if !first {
+				b.WriteString(", ")
+			}
+			first = false
+			fmt.Fprintf(b, "%s=%s", k, v)
+		}
+		b.WriteString("\n")
+	}
+}
+
+// boundSQL 将参数填入 SQL，便于日志查看
+func boundSQL(sql string, args []any) string {
+	s, err := builder.ConvertToBoundSQL(sql, args)
+	if err != nil {
+		return sql
+	}
+	return s
+}
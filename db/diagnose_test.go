@@ -0,0 +1,47 @@
+package db
+
+import "testing"
+
+func TestDeadlockSection(t *testing.T) {
+	status := `
+=====================================
+2024-01-01 10:00:00 INNODB MONITOR OUTPUT
+=====================================
+------------------------
+LATEST DETECTED DEADLOCK
+------------------------
+2024-01-01 09:59:58 0x7f
+*** (1) TRANSACTION:
+TRANSACTION 1001, ACTIVE 3 sec starting index read
+*** (2) TRANSACTION:
+TRANSACTION 1002, ACTIVE 2 sec starting index read
+*** WE ROLL BACK TRANSACTION (2)
+------------
+TRANSACTIONS
+------------
+Trx id counter 1003
+`
+	want := `LATEST DETECTED DEADLOCK
+2024-01-01 09:59:58 0x7f
+*** (1) TRANSACTION:
+TRANSACTION 1001, ACTIVE 3 sec starting index read
+*** (2) TRANSACTION:
+TRANSACTION 1002, ACTIVE 2 sec starting index read
+*** WE ROLL BACK TRANSACTION (2)`
+
+	tests := []struct {
+		name   string
+		status string
+		want   string
+	}{
+		{"section", status, want},
+		{"last section", "LATEST DETECTED DEADLOCK\n----\n*** (1) TRANSACTION:\n", "LATEST DETECTED DEADLOCK\n*** (1) TRANSACTION:"},
+		{"no deadlock", "------------\nTRANSACTIONS\n------------\n", ""},
+		{"title only", "LATEST DETECTED DEADLOCK", "LATEST DETECTED DEADLOCK"},
+	}
+	for _, tt := range tests {
+		if got := deadlockSection(tt.status); got != tt.want {
+			t.Errorf("%s: deadlockSection() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package db
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// 导出时每写入多少行刷新一次缓冲，便于边查边输出
+const exportFlushRows = 1000
+
+// Column 导出列映射：表头和取值函数
+type Column[T any] struct {
+	Header string
+	Value  func(T) any
+}
+
+// CSVOptions CSV 导出选项
+type CSVOptions struct {
+	BOM        bool   // 是否写入 UTF-8 BOM（Excel 打开中文不乱码）
+	Comma      rune   // 分隔符，默认逗号
+	TimeLayout string // 时间格式，默认 2006-01-02 15:04:05
+}
+
+// ColumnsOf 根据名为 name 的数据库的表结构映射生成 T 的全部导出列，表头为字段对应的列名
+func ColumnsOf[T any](name string) ([]Column[T], error) {
+	g, err := get(name)
+	if err != nil {
+		return nil, err
+	}
+	table, err := g.TableInfo(new(T))
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]Column[T], 0, len(table.Columns()))
+	for _, col := range table.Columns() {
+		index := col.FieldIndex
+		columns = append(columns, Column[T]{
+			Header: col.Name,
+			Value: func(row T) any {
+				return reflect.Indirect(reflect.ValueOf(row)).FieldByIndex(index).Interface()
+			},
+		})
+	}
+	return columns, nil
+}
+
+// WriteCSV 将数据流写入 CSV，返回写入的行数（不含表头）
+func WriteCSV[T any](w io.Writer, rows iter.Seq2[T, error], columns []Column[T], opts CSVOptions) (int, error) {
+	if opts.TimeLayout == "" {
+		opts.TimeLayout = time.DateTime
+	}
+	if opts.BOM {
+		if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+			return 0, err
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	if opts.Comma != 0 {
+		cw.Comma = opts.Comma
+	}
+
+	record := make([]string, len(columns))
+	for i, c := range columns {
+		record[i] = c.Header
+	}
+	if err := cw.Write(record); err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for row, err := range rows {
+		if err != nil {
+			cw.Flush()
+			return n, err
+		}
+		for i, c := range columns {
+			record[i] = formatCell(c.Value(row), opts.TimeLayout)
+		}
+		if err = cw.Write(record); err != nil {
+			return n, err
+		}
+		n++
+		if n%exportFlushRows == 0 {
+			cw.Flush()
+			if err = cw.Error(); err != nil {
+				return n, err
+			}
+		}
+	}
+	cw.Flush()
+	return n, cw.Error()
+}
+
+// WriteJSONL 将数据流按 JSON Lines 格式写入，columns 为空时直接序列化整条记录
+func WriteJSONL[T any](w io.Writer, rows iter.Seq2[T, error], columns []Column[T]) (int, error) {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	n := 0
+	for row, err := range rows {
+		if err != nil {
+			return n, err
+		}
+		if len(columns) == 0 {
+			err = enc.Encode(row)
+		} else {
+			obj := orderedObject{keys: make([]string, len(columns)), values: make([]any, len(columns))}
+			for i, c := range columns {
+				obj.keys[i], obj.values[i] = c.Header, c.Value(row)
+			}
+			err = enc.Encode(obj)
+		}
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// orderedObject 按列顺序序列化的 JSON 对象
+type orderedObject struct {
+	keys   []string
+	values []any
+}
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := enc.Encode(key); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1) // 去掉 Encode 追加的换行
+		buf.WriteByte(':')
+		if err := enc.Encode(o.values[i]); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// escapeFormula 文本以 = + - @ 或制表符、回车开头时加上单引号，防止在 Excel 中被当作公式执行
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// formatCell 将单元格的值格式化为字符串，文本按 escapeFormula 转义，数值保持原样
+func formatCell(v any, timeLayout string) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return escapeFormula(val)
+	case []byte:
+		return escapeFormula(string(val))
+	case time.Time:
+		if val.IsZero() {
+			return ""
+		}
+		return val.Format(timeLayout)
+	case *time.Time:
+		if val == nil || val.IsZero() {
+			return ""
+		}
+		return val.Format(timeLayout)
+	case fmt.Stringer:
+		return escapeFormula(val.String())
+	default:
+		if reflect.ValueOf(val).Kind() == reflect.String {
+			return escapeFormula(fmt.Sprint(val))
+		}
+		return fmt.Sprint(val)
+	}
+}
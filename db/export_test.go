@@ -0,0 +1,49 @@
+package db
+
+import (
+	"bytes"
+	"iter"
+	"testing"
+)
+
+type exportRow struct {
+	Name  string
+	Score int
+}
+
+func exportRows(rows ...exportRow) iter.Seq2[exportRow, error] {
+	return func(yield func(exportRow, error) bool) {
+		for _, r := range rows {
+			if !yield(r, nil) {
+				return
+			}
+		}
+	}
+}
+
+func TestWriteExport(t *testing.T) {
+	columns := []Column[exportRow]{
+		{Header: "name", Value: func(r exportRow) any { return r.Name }},
+		{Header: "score", Value: func(r exportRow) any { return r.Score }},
+		{Header: "a", Value: func(r exportRow) any { return "<x>" }},
+	}
+	rows := exportRows(exportRow{Name: "=1+1", Score: -5}, exportRow{Name: "@SUM(A1)", Score: 3})
+
+	// 文本单元格防公式注入，数值保持原样
+	var buf bytes.Buffer
+	if _, err := WriteCSV(&buf, rows, columns, CSVOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "name,score,a\n'=1+1,-5,<x>\n'@SUM(A1),3,<x>\n"; buf.String() != want {
+		t.Fatalf("csv = %q, want %q", buf.String(), want)
+	}
+
+	// JSONL 按列顺序输出
+	buf.Reset()
+	if _, err := WriteJSONL(&buf, rows, columns); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\"name\":\"=1+1\",\"score\":-5,\"a\":\"<x>\"}\n{\"name\":\"@SUM(A1)\",\"score\":3,\"a\":\"<x>\"}\n"; buf.String() != want {
+		t.Fatalf("jsonl = %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// HealthStatus 单个数据库的健康状态
+type HealthStatus struct {
+	Up       bool          `json:"up"`                // 主库是否可用
+	Error    string        `json:"error,omitempty"`   // ping 失败原因
+	Latency  time.Duration `json:"latency"`           // ping 耗时
+	ReadOnly bool          `json:"read_only"`         // 是否处于只读模式
+	Reason   string        `json:"reason,omitempty"`  // 只读原因
+	Breaker  string        `json:"breaker,omitempty"` // 熔断器状态，未配置时为空
+}
+
+// Health 检查所有数据库主库的连通性，并返回只读与熔断器状态
+func Health(ctx context.Context) map[string]HealthStatus {
+	groups := engineGroups()
+	result := make(map[string]HealthStatus, len(groups))
+	for name, g := range groups {
+		var status HealthStatus
+		start := time.Now()
+		if err := g.Master().PingContext(ctx); err != nil {
+			status.Error = err.Error()
+		} else {
+			status.Up = true
+		}
+		status.Latency = time.Since(start)
+		status.ReadOnly, status.Reason = IsReadOnly(name)
+		if state, ok := BreakerState(name); ok {
+			status.Breaker = state.String()
+		}
+		result[name] = status
+	}
+	return result
+}
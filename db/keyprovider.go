@@ -0,0 +1,114 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeyProvider 字段加密密钥提供者，密钥长度需为 16、24 或 32 字节（AES-128/192/256）
+type KeyProvider interface {
+	// CurrentKey 返回加密使用的当前密钥及其 ID
+	CurrentKey() (id string, key []byte, err error)
+	// Key 按 ID 返回密钥，用于解密使用历史密钥加密的数据
+	Key(id string) ([]byte, error)
+	// BlindKey 返回计算盲索引使用的 HMAC 密钥，轮换后已有盲索引将失效
+	BlindKey() ([]byte, error)
+}
+
+// staticKeys 内存中的密钥集合
+type staticKeys struct {
+	current string
+	keys    map[string][]byte
+	blind   []byte
+}
+
+func (s *staticKeys) CurrentKey() (string, []byte, error) {
+	key, err := s.Key(s.current)
+	return s.current, key, err
+}
+
+func (s *staticKeys) Key(id string) ([]byte, error) {
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("crypto key does not exist:[%s]", id)
+	}
+	return key, nil
+}
+
+func (s *staticKeys) BlindKey() ([]byte, error) {
+	if len(s.blind) == 0 {
+		return nil, fmt.Errorf("crypto blind key is not configured")
+	}
+	return s.blind, nil
+}
+
+// NewEnvKeyProvider 从环境变量读取密钥（密钥均为 base64 编码）：
+//
+//	<PREFIX>_KEYS=v1:base64key,v2:base64key
+//	<PREFIX>_CURRENT=v2
+//	<PREFIX>_BLIND_KEY=base64key
+func NewEnvKeyProvider(prefix string) (KeyProvider, error) {
+	keys := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv(prefix+"_KEYS"), ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		id, key, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid crypto key entry in %s_KEYS", prefix)
+		}
+		keys[id] = key
+	}
+	return newStaticKeys(os.Getenv(prefix+"_CURRENT"), keys, os.Getenv(prefix+"_BLIND_KEY"))
+}
+
+// NewFileKeyProvider 从 JSON 文件读取密钥（密钥均为 base64 编码）：
+//
+//	{"current": "v2", "keys": {"v1": "...", "v2": "..."}, "blind_key": "..."}
+func NewFileKeyProvider(path string) (KeyProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Current  string            `json:"current"`
+		Keys     map[string]string `json:"keys"`
+		BlindKey string            `json:"blind_key"`
+	}
+	if err = json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析密钥文件失败: %w", err)
+	}
+	return newStaticKeys(file.Current, file.Keys, file.BlindKey)
+}
+
+// newStaticKeys 解码并校验密钥
+func newStaticKeys(current string, encoded map[string]string, blind string) (*staticKeys, error) {
+	s := &staticKeys{current: current, keys: make(map[string][]byte, len(encoded))}
+	for id, v := range encoded {
+		if strings.Contains(id, ":") {
+			return nil, fmt.Errorf("crypto key id must not contain ':':[%s]", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return nil, fmt.Errorf("crypto key is not valid base64:[%s]", id)
+		}
+		if n := len(key); n != 16 && n != 24 && n != 32 {
+			return nil, fmt.Errorf("crypto key must be 16, 24 or 32 bytes:[%s]", id)
+		}
+		s.keys[id] = key
+	}
+	if _, ok := s.keys[current]; !ok {
+		return nil, fmt.Errorf("current crypto key does not exist:[%s]", current)
+	}
+	if blind != "" {
+		key, err := base64.StdEncoding.DecodeString(blind)
+		if err != nil {
+			return nil, fmt.Errorf("crypto blind key is not valid base64")
+		}
+		s.blind = key
+	}
+	return s, nil
+}
@@ -0,0 +1,151 @@
+package db
+
+import (
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// SessionInfo 未关闭会话的快照信息
+type SessionInfo struct {
+	Name      string        `json:"name"`       // 数据库名称
+	CreatedAt time.Time     `json:"created_at"` // 创建时间
+	Age       time.Duration `json:"age"`        // 已打开时长
+	InTx      bool          `json:"in_tx"`      // 是否处于事务中
+	Stack     string        `json:"stack"`      // 创建时的调用堆栈（仅开启检测时记录）
+}
+
+// DefaultLeakReportInterval 泄漏报告的默认间隔
+const DefaultLeakReportInterval = time.Minute
+
+var (
+	tracking     atomic.Bool   // 是否记录会话创建堆栈
+	trackMu      sync.Mutex    // 保护 trackStop
+	trackStop    chan struct{} // 停止泄漏报告协程
+	maxStackSize = 32          // 记录的最大堆栈深度
+)
+
+// EnableSessionTracking 开启会话泄漏检测（调试模式）：记录每个会话的创建堆栈，
+// 每隔 interval 将打开时间超过 threshold 的会话输出到 SQL 日志（每个会话只报告一次）
+// interval 小于等于 0 时使用 DefaultLeakReportInterval
+func EnableSessionTracking(threshold, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultLeakReportInterval
+	}
+	trackMu.Lock()
+	defer trackMu.Unlock()
+
+	if trackStop != nil {
+		close(trackStop)
+	}
+	stop := make(chan struct{})
+	trackStop = stop
+	tracking.Store(true)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				reportLeaks(threshold)
+			}
+		}
+	}()
+}
+
+// DisableSessionTracking 关闭会话泄漏检测
+func DisableSessionTracking() {
+	trackMu.Lock()
+	defer trackMu.Unlock()
+
+	tracking.Store(false)
+	if trackStop != nil {
+		close(trackStop)
+		trackStop = nil
+	}
+}
+
+// SessionSnapshot 返回当前所有未关闭会话的快照，按创建时间排序
+// 未经 db.Close 而直接调用 session.Close() 的会话在被回收前仍会出现在快照中
+func SessionSnapshot() []SessionInfo {
+	now := time.Now()
+	var list []SessionInfo
+	sessions.Range(func(_, v any) bool {
+		meta := v.(*sessionMeta)
+		list = append(list, SessionInfo{
+			Name:      meta.name,
+			CreatedAt: meta.createdAt,
+			Age:       now.Sub(meta.createdAt),
+			InTx:      meta.inTx.Load(),
+			Stack:     meta.stack,
+		})
+		return true
+	})
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list
+}
+
+// LeakedSessions 返回打开时间超过 threshold 的会话，可在测试结束时断言为空
+func LeakedSessions(threshold time.Duration) []SessionInfo {
+	var leaked []SessionInfo
+	for _, s := range SessionSnapshot() {
+		if s.Age >= threshold {
+			leaked = append(leaked, s)
+		}
+	}
+	return leaked
+}
+
+// reportLeaks 输出疑似泄漏的会话
+func reportLeaks(threshold time.Duration) {
+	if sqlLogger == nil {
+		return
+	}
+	now := time.Now()
+	sessions.Range(func(_, v any) bool {
+		meta := v.(*sessionMeta)
+		if now.Sub(meta.createdAt) >= threshold && meta.reported.CompareAndSwap(false, true) {
+			sqlLogger.Warn("数据库会话疑似泄漏",
+				zap.String("name", meta.name),
+				zap.Duration("age", now.Sub(meta.createdAt)),
+				zap.String("stack", meta.stack),
+			)
+		}
+		return true
+	})
+}
+
+// captureStack 记录调用堆栈，跳过本包内部的调用
+func captureStack() string {
+	pcs := make([]uintptr, maxStackSize)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var sb strings.Builder
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "github.com/restoflife/ql_common/db.") {
+			sb.WriteString(frame.Function)
+			sb.WriteString("\n\t")
+			sb.WriteString(frame.File)
+			sb.WriteString(":")
+			sb.WriteString(strconv.Itoa(frame.Line))
+			sb.WriteString("\n")
+		}
+		if !more {
+			break
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,154 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// ErrNotFound 查询不到记录，可通过 errors.Is 判断
+var ErrNotFound = errors.New("record not found")
+
+// txSessionKey context 中保存事务会话的 key，按数据库名称区分
+type txSessionKey struct {
+	name string
+}
+
+// WithSession 将会话（通常是事务会话）放入 context，同名数据库的 Get/Find 等查询函数会复用该会话
+func WithSession(ctx context.Context, name string, session *xorm.Session) context.Context {
+	return context.WithValue(ctx, txSessionKey{name}, session)
+}
+
+// SessionFromContext 获取 context 中指定数据库的会话
+func SessionFromContext(ctx context.Context, name string) (*xorm.Session, bool) {
+	session, ok := ctx.Value(txSessionKey{name}).(*xorm.Session)
+	return session, ok && session != nil
+}
+
+// detachSession 移除 context 中指定数据库的会话，使 Transaction 开启独立事务而不加入调用方的事务
+func detachSession(ctx context.Context, name string) context.Context {
+	if _, ok := SessionFromContext(ctx, name); !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, txSessionKey{name}, (*xorm.Session)(nil))
+}
+
+// TransactionContext 与 Transaction 相同，fn 收到的 context 中携带事务会话，
+// 其中调用的 Get/Find/Count/Exists/Pluck 会自动加入该事务
+func TransactionContext(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	return Transaction(ctx, name, func(session *xorm.Session) error {
+		return fn(WithSession(ctx, name, session))
+	})
+}
+
+// QueryOption 查询的附加条件
+type QueryOption func(*xorm.Session)
+
+// OrderBy 设置排序，如 "id DESC"
+func OrderBy(order string) QueryOption {
+	return func(s *xorm.Session) {
+		s.OrderBy(order)
+	}
+}
+
+// Limit 设置返回条数与偏移量
+func Limit(limit int, start ...int) QueryOption {
+	return func(s *xorm.Session) {
+		s.Limit(limit, start...)
+	}
+}
+
+// Cols 只查询指定字段
+func Cols(cols ...string) QueryOption {
+	return func(s *xorm.Session) {
+		s.Cols(cols...)
+	}
+}
+
+// Get 按条件查询一条记录，不存在时返回 ErrNotFound
+//
+//	user, err := db.Get[User](ctx, "default", builder.Eq{"id": 1})
+func Get[T any](ctx context.Context, name string, cond builder.Cond, opts ...QueryOption) (*T, error) {
+	var row *T
+	err := query(ctx, name, cond, opts, func(s *xorm.Session) error {
+		bean := new(T)
+		has, err := s.Get(bean)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return ErrNotFound
+		}
+		row = bean
+		return nil
+	})
+	return row, err
+}
+
+// Find 按条件查询多条记录
+func Find[T any](ctx context.Context, name string, cond builder.Cond, opts ...QueryOption) ([]T, error) {
+	var rows []T
+	err := query(ctx, name, cond, opts, func(s *xorm.Session) error {
+		return s.Find(&rows)
+	})
+	return rows, err
+}
+
+// Count 按条件统计记录数
+func Count[T any](ctx context.Context, name string, cond builder.Cond) (int64, error) {
+	var n int64
+	err := query(ctx, name, cond, nil, func(s *xorm.Session) (err error) {
+		n, err = s.Count(new(T))
+		return err
+	})
+	return n, err
+}
+
+// Exists 判断是否存在满足条件的记录
+func Exists[T any](ctx context.Context, name string, cond builder.Cond) (bool, error) {
+	var has bool
+	err := query(ctx, name, cond, nil, func(s *xorm.Session) (err error) {
+		has, err = s.Exist(new(T))
+		return err
+	})
+	return has, err
+}
+
+// Pluck 查询单个字段的值列表
+//
+//	ids, err := db.Pluck[User, int64](ctx, "default", "id", builder.Eq{"status": 1})
+func Pluck[T, V any](ctx context.Context, name, column string, cond builder.Cond, opts ...QueryOption) ([]V, error) {
+	var values []V
+	err := query(ctx, name, cond, opts, func(s *xorm.Session) error {
+		return s.Table(new(T)).Cols(column).Find(&values)
+	})
+	return values, err
+}
+
+// query 获取会话并应用条件后执行 fn；context 中有同名数据库的会话时复用，否则新建并在结束后关闭
+func query(ctx context.Context, name string, cond builder.Cond, opts []QueryOption, fn func(*xorm.Session) error) error {
+	session, ok := SessionFromContext(ctx, name)
+	if !ok {
+		var err error
+		if session, err = NewSessionContext(ctx, name); err != nil {
+			return err
+		}
+		defer Close(session)
+	}
+
+	if cond != nil {
+		session.Where(cond)
+	}
+	for _, opt := range opts {
+		opt(session)
+	}
+	if err := fn(session); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return err
+		}
+		return WrapTimeout(session, err)
+	}
+	return nil
+}
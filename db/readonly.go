@@ -0,0 +1,173 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"go.uber.org/zap"
+	"xorm.io/xorm/contexts"
+)
+
+// ErrReadOnly 数据库处于只读（维护）模式，可通过 errors.Is 判断
+var ErrReadOnly = errors.New("database is read-only")
+
+// ReadOnlyError 数据库只读时拒绝写操作的错误
+type ReadOnlyError struct {
+	Name   string // 数据库名称
+	Reason string // 切换为只读的原因
+}
+
+func (e *ReadOnlyError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("database [%s] is read-only", e.Name)
+	}
+	return fmt.Sprintf("database [%s] is read-only: %s", e.Name, e.Reason)
+}
+
+// Is 使 errors.Is(err, ErrReadOnly) 成立
+func (e *ReadOnlyError) Is(target error) bool {
+	return target == ErrReadOnly
+}
+
+// readOnlyState 只读模式信息
+type readOnlyState struct {
+	reason string
+	since  time.Time
+}
+
+// 处于只读模式的数据库
+var readOnlyMgr sync.Map // map[string]*readOnlyState
+
+// SetReadOnly 运行时切换数据库的只读模式：只读时 Transaction 与写语句立即返回 *ReadOnlyError，读操作不受影响
+func SetReadOnly(name string, readOnly bool, reason string) error {
+	if _, err := get(name); err != nil {
+		return err
+	}
+	if readOnly {
+		readOnlyMgr.Store(name, &readOnlyState{reason: reason, since: time.Now()})
+	} else {
+		readOnlyMgr.Delete(name)
+	}
+	if sqlLogger != nil {
+		sqlLogger.Warn("数据库只读模式变化",
+			zap.String("name", name),
+			zap.Bool("read_only", readOnly),
+			zap.String("reason", reason),
+		)
+	}
+	return nil
+}
+
+// IsReadOnly 返回数据库是否处于只读模式及原因
+func IsReadOnly(name string) (bool, string) {
+	v, ok := readOnlyMgr.Load(name)
+	if !ok {
+		return false, ""
+	}
+	return true, v.(*readOnlyState).reason
+}
+
+// checkReadOnly 数据库只读时返回 *ReadOnlyError
+func checkReadOnly(name string) error {
+	if ok, reason := IsReadOnly(name); ok {
+		return &ReadOnlyError{Name: name, Reason: reason}
+	}
+	return nil
+}
+
+// 会修改数据或结构的语句
+var writeVerbs = map[string]struct{}{
+	"INSERT": {}, "UPDATE": {}, "DELETE": {}, "REPLACE": {}, "MERGE": {}, "UPSERT": {},
+	"CREATE": {}, "ALTER": {}, "DROP": {}, "TRUNCATE": {}, "RENAME": {},
+	"GRANT": {}, "REVOKE": {}, "LOAD": {},
+}
+
+// isWriteSQL 根据首个关键字判断是否为写语句
+// WITH 语句中可以包含写操作，只有其中不出现任何写关键字时才视为只读
+func isWriteSQL(sql string) bool {
+	words := sqlWords(skipLeadingComments(sql))
+	if len(words) == 0 {
+		return false
+	}
+	verb := strings.ToUpper(words[0])
+	if verb != "WITH" {
+		_, ok := writeVerbs[verb]
+		return ok
+	}
+	for _, w := range sqlWords(stripQuoted(sql)) {
+		if _, ok := writeVerbs[strings.ToUpper(w)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sqlWords 按空白、括号、注释符号及标点切分 SQL
+func sqlWords(sql string) []string {
+	return strings.FieldsFunc(sql, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+	})
+}
+
+// skipLeadingComments 跳过开头的空白、括号和注释（/* */、-- 和 #）
+func skipLeadingComments(sql string) string {
+	for {
+		sql = strings.TrimLeftFunc(sql, func(r rune) bool { return unicode.IsSpace(r) || r == '(' })
+		switch {
+		case strings.HasPrefix(sql, "/*"):
+			end := strings.Index(sql, "*/")
+			if end < 0 {
+				return ""
+			}
+			sql = sql[end+2:]
+		case strings.HasPrefix(sql, "--"), strings.HasPrefix(sql, "#"):
+			end := strings.IndexByte(sql, '\n')
+			if end < 0 {
+				return ""
+			}
+			sql = sql[end+1:]
+		default:
+			return sql
+		}
+	}
+}
+
+// stripQuoted 去掉单引号字符串常量，避免其中的文字被当作关键字
+func stripQuoted(sql string) string {
+	var sb strings.Builder
+	quoted := false
+	for _, r := range sql {
+		if r == '\'' {
+			quoted = !quoted
+			sb.WriteByte(' ')
+			continue
+		}
+		if !quoted {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// readOnlyHook 数据库只读时拒绝写语句
+type readOnlyHook struct {
+	name string
+}
+
+func (h *readOnlyHook) BeforeProcess(c *contexts.ContextHook) (context.Context, error) {
+	if isWriteSQL(c.SQL) {
+		if err := checkReadOnly(h.name); err != nil {
+			return nil, err
+		}
+	}
+	return c.Ctx, nil
+}
+
+func (h *readOnlyHook) AfterProcess(*contexts.ContextHook) error {
+	return nil
+}
@@ -0,0 +1,31 @@
+package db
+
+import "testing"
+
+func TestIsWriteSQL(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want bool
+	}{
+		{"SELECT * FROM t", false},
+		{"select 1", false},
+		{"(SELECT 1) UNION (SELECT 2)", false},
+		{"INSERT INTO t VALUES (1)", true},
+		{"DELETE\nFROM t", true},
+		{"UPDATE\tt SET a = 1", true},
+		{"INSERT/*x*/INTO t VALUES (1)", true},
+		{"/* hint */ UPDATE t SET a = 1", true},
+		{"-- comment\nDELETE FROM t", true},
+		{"# comment\nDELETE FROM t", true},
+		{"-- DELETE FROM t\nSELECT 1", false},
+		{"WITH a AS (SELECT 1) SELECT * FROM a", false},
+		{"WITH a AS (SELECT id FROM t) DELETE FROM t WHERE id IN (SELECT id FROM a)", true},
+		{"WITH a AS (SELECT 1)\nUPDATE t SET b = 1", true},
+		{"WITH a AS (SELECT 'delete' AS s) SELECT s FROM a", false},
+	}
+	for _, tt := range tests {
+		if got := isWriteSQL(tt.sql); got != tt.want {
+			t.Errorf("isWriteSQL(%q) = %v, want %v", tt.sql, got, tt.want)
+		}
+	}
+}
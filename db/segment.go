@@ -0,0 +1,186 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"xorm.io/xorm"
+)
+
+// DefaultSegmentStep 自动创建业务标识时使用的默认号段步长
+const DefaultSegmentStep = 1000
+
+// IDSegment 号段表，每个业务标识一行，记录已分配的最大 ID
+type IDSegment struct {
+	BizTag      string    `xorm:"varchar(128) pk 'biz_tag'" json:"biz_tag"`
+	MaxId       int64     `xorm:"bigint notnull 'max_id'" json:"max_id"`
+	Step        int64     `xorm:"bigint notnull 'step'" json:"step"`
+	Description string    `xorm:"varchar(256) 'description'" json:"description"`
+	UpdateTime  time.Time `xorm:"updated 'update_time'" json:"update_time"`
+}
+
+// TableName 号段表名
+func (IDSegment) TableName() string {
+	return "id_segment"
+}
+
+// segment 内存中的一个号段 [value, max]
+type segment struct {
+	value int64 // 下一个可分配的 ID
+	max   int64 // 号段内最大 ID
+	step  int64 // 号段步长
+}
+
+// idle 号段剩余可用数量
+func (s *segment) idle() int64 {
+	return s.max - s.value + 1
+}
+
+// segmentBuffer 双缓冲：当前号段消耗超过 10% 时异步预取下一个号段
+type segmentBuffer struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	segments  [2]*segment
+	current   int  // 当前使用的号段下标
+	nextReady bool // 下一个号段是否已就绪
+	loading   bool // 是否正在预取
+}
+
+// SegmentAllocator 基于数据库号段的分布式 ID 分配器（Leaf-segment 模式）
+type SegmentAllocator struct {
+	name    string // 数据库名称
+	step    int64  // 自动创建业务标识时的步长
+	mu      sync.Mutex
+	buffers map[string]*segmentBuffer
+}
+
+// NewSegmentAllocator 创建号段分配器，号段表位于名为 name 的数据库
+// step 为业务标识不存在时自动创建使用的步长，<=0 时使用 DefaultSegmentStep
+func NewSegmentAllocator(name string, step int64) *SegmentAllocator {
+	if step <= 0 {
+		step = DefaultSegmentStep
+	}
+	return &SegmentAllocator{
+		name:    name,
+		step:    step,
+		buffers: make(map[string]*segmentBuffer),
+	}
+}
+
+// NextID 获取业务标识 tag 的下一个 ID
+func (a *SegmentAllocator) NextID(ctx context.Context, tag string) (int64, error) {
+	buf := a.buffer(tag)
+
+	buf.mu.Lock()
+	defer buf.mu.Unlock()
+	for {
+		seg := buf.segments[buf.current]
+		if seg != nil {
+			// 消耗超过 10% 时异步预取下一个号段
+			if !buf.nextReady && !buf.loading && seg.idle() < seg.step*9/10 {
+				buf.loading = true
+				go a.prefetch(tag, buf)
+			}
+			if seg.value <= seg.max {
+				id := seg.value
+				seg.value++
+				return id, nil
+			}
+			if buf.nextReady {
+				buf.current ^= 1
+				buf.nextReady = false
+				continue
+			}
+		}
+		if buf.loading {
+			buf.cond.Wait()
+			continue
+		}
+
+		// 没有可用号段，同步加载
+		next, err := a.loadSegment(ctx, tag)
+		if err != nil {
+			return 0, err
+		}
+		buf.segments[buf.current] = next
+	}
+}
+
+// buffer 获取业务标识对应的双缓冲
+func (a *SegmentAllocator) buffer(tag string) *segmentBuffer {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	buf, ok := a.buffers[tag]
+	if !ok {
+		buf = &segmentBuffer{}
+		buf.cond = sync.NewCond(&buf.mu)
+		a.buffers[tag] = buf
+	}
+	return buf
+}
+
+// prefetch 异步加载下一个号段
+func (a *SegmentAllocator) prefetch(tag string, buf *segmentBuffer) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	next, err := a.loadSegment(ctx, tag)
+
+	buf.mu.Lock()
+	defer buf.mu.Unlock()
+	buf.loading = false
+	if err != nil {
+		if sqlLogger != nil {
+			sqlLogger.Error("号段预取失败", zap.String("name", a.name), zap.String("biz_tag", tag), zap.Error(err))
+		}
+	} else {
+		buf.segments[buf.current^1] = next
+		buf.nextReady = true
+	}
+	buf.cond.Broadcast()
+}
+
+// loadSegment 在事务中将号段表的 max_id 增加一个步长，返回新的号段
+func (a *SegmentAllocator) loadSegment(ctx context.Context, tag string) (*segment, error) {
+	seg, created, err := a.allocate(ctx, tag)
+	if err != nil && created {
+		// 多个进程同时自动创建同一业务标识时，插入失败的一方重试即可走更新分支
+		seg, _, err = a.allocate(ctx, tag)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if seg.Step <= 0 {
+		return nil, fmt.Errorf("id segment step must be positive:[%s]", tag)
+	}
+	return &segment{value: seg.MaxId - seg.Step + 1, max: seg.MaxId, step: seg.Step}, nil
+}
+
+// allocate 执行一次号段分配，created 表示是否尝试了自动创建业务标识
+// 分配在独立事务中提交：号段一旦发出就不能随调用方的事务回滚，否则其他节点会拿到相同的号段
+func (a *SegmentAllocator) allocate(ctx context.Context, tag string) (seg IDSegment, created bool, err error) {
+	err = Transaction(detachSession(ctx, a.name), a.name, func(session *xorm.Session) error {
+		affected, err := session.Exec("UPDATE "+seg.TableName()+" SET max_id = max_id + step, update_time = ? WHERE biz_tag = ?", time.Now(), tag)
+		if err != nil {
+			return err
+		}
+		if n, _ := affected.RowsAffected(); n == 0 {
+			// 业务标识不存在时自动创建
+			created = true
+			seg = IDSegment{BizTag: tag, MaxId: a.step, Step: a.step}
+			_, err = session.Insert(&seg)
+			return err
+		}
+		has, err := session.Where("biz_tag = ?", tag).Get(&seg)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return fmt.Errorf("id segment does not exist:[%s]", tag)
+		}
+		return nil
+	})
+	return seg, created, err
+}
@@ -0,0 +1,84 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/restoflife/ql_common/db"
+	"github.com/restoflife/ql_common/db/dbtest"
+)
+
+func TestSegmentAllocator(t *testing.T) {
+	dbtest.Open(t, "default", dbtest.WithModels(new(db.IDSegment)))
+	ctx := context.Background()
+
+	// 步长为 10，连续取号会经历自动创建、预取与号段切换（停在 32，此时没有进行中的预取）
+	a := db.NewSegmentAllocator("default", 10)
+	for want := int64(1); want <= 32; want++ {
+		id, err := a.NextID(ctx, "order")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != want {
+			t.Fatalf("NextID = %d, want %d", id, want)
+		}
+	}
+
+	// 另一个分配器从已分配的最大 ID 之后继续
+	b := db.NewSegmentAllocator("default", 10)
+	id, err := b.NextID(ctx, "order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id <= 32 {
+		t.Fatalf("second allocator NextID = %d, want > 32", id)
+	}
+}
+
+func TestLeasedSnowflake(t *testing.T) {
+	dbtest.Open(t, "default", dbtest.WithModels(new(db.IDSegment)))
+	ctx := context.Background()
+
+	if _, err := db.NewLeasedSnowflake(ctx, "default", time.Millisecond); err == nil {
+		t.Fatal("expected error for ttl < 1s")
+	}
+	// 数据库错误（号段表不存在）原样返回，而不是 ErrNoWorkerID
+	dbtest.Open(t, "empty")
+	if _, err := db.NewLeasedSnowflake(ctx, "empty", time.Second); err == nil || errors.Is(err, db.ErrNoWorkerID) {
+		t.Fatalf("err = %v, want database error", err)
+	}
+
+	first, err := db.NewLeasedSnowflake(ctx, "default", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := db.NewLeasedSnowflake(ctx, "default", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close(ctx)
+	if first.WorkerID() == second.WorkerID() {
+		t.Fatalf("duplicate worker id %d", first.WorkerID())
+	}
+
+	// 释放后机器号可被重新租用，重复释放不会 panic
+	if err = first.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err = first.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	third, err := db.NewLeasedSnowflake(ctx, "default", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer third.Close(ctx)
+	if third.WorkerID() != first.WorkerID() {
+		t.Fatalf("worker id = %d, want released %d", third.WorkerID(), first.WorkerID())
+	}
+	if _, err = third.NextID(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,142 @@
+package db
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+	"weak"
+
+	"go.uber.org/zap"
+	"xorm.io/xorm"
+	"xorm.io/xorm/contexts"
+)
+
+// sessionMeta 记录由本包创建的会话的附加信息
+type sessionMeta struct {
+	name    string             // 数据库名称
+	ctx     context.Context    // 会话绑定的 context
+	timeout time.Duration      // 生效的会话超时（事务超时），context 自带截止时间时为 0
+	cancel  context.CancelFunc // 取消会话 context（默认超时或关闭时强制取消）
+	limited bool               // 调用方 context 是否自带截止时间，自带时不再应用单条 SQL 默认超时
+
+	inTx      atomic.Bool  // 是否处于事务中，由 sessionHook 维护，避免跨协程读取会话内部状态
+	running   atomic.Int32 // 正在执行的 SQL 数
+	createdAt time.Time    // 创建时间
+	stack     string       // 创建堆栈，仅开启泄漏检测时记录
+	reported  atomic.Bool  // 是否已报告为疑似泄漏
+}
+
+// 所有未关闭的会话，以弱引用为 key：直接调用 session.Close() 而未经过 db.Close 的会话在被回收时自动移除
+var sessions sync.Map // map[weak.Pointer[xorm.Session]]*sessionMeta
+
+// sessionMetaKey 会话 context 中保存附加信息的 key，供 SQL 钩子读取
+type sessionMetaKey struct{}
+
+// newSession 创建会话，context 未设置截止时间时使用 timeout 作为整个会话的超时（为 0 时不限制）
+func newSession(ctx context.Context, name string, timeout time.Duration) (*xorm.Session, error) {
+	// 关闭开始后不再创建新会话
+	lifecycleMu.RLock()
+	defer lifecycleMu.RUnlock()
+	if closing.Load() {
+		return nil, ErrShutdown
+	}
+
+	g, err := lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkBreaker(name); err != nil {
+		return nil, err
+	}
+
+	meta := &sessionMeta{name: name, createdAt: time.Now()}
+	if tracking.Load() {
+		meta.stack = captureStack()
+	}
+	_, meta.limited = ctx.Deadline()
+	if !meta.limited && timeout > 0 {
+		ctx, meta.cancel = context.WithTimeout(ctx, timeout)
+		meta.timeout = timeout
+	} else {
+		ctx, meta.cancel = context.WithCancel(ctx)
+	}
+	ctx = context.WithValue(ctx, sessionMetaKey{}, meta)
+	meta.ctx = ctx
+
+	session := g.NewSession().Context(ctx)
+	key := weak.Make(session)
+	sessions.Store(key, meta)
+	runtime.AddCleanup(session, collectSession, key)
+	return session, nil
+}
+
+// releaseSession 释放会话的附加资源
+func releaseSession(session *xorm.Session) {
+	if v, ok := sessions.LoadAndDelete(weak.Make(session)); ok {
+		v.(*sessionMeta).cancel()
+	}
+}
+
+// collectSession 会话被回收时释放附加资源，仍处于事务中说明会话未关闭，连接已无法归还
+func collectSession(key weak.Pointer[xorm.Session]) {
+	v, ok := sessions.LoadAndDelete(key)
+	if !ok {
+		return
+	}
+	meta := v.(*sessionMeta)
+	meta.cancel()
+	if meta.inTx.Load() && sqlLogger != nil {
+		sqlLogger.Warn("数据库会话未关闭即被回收，事务未提交或回滚",
+			zap.String("name", meta.name),
+			zap.Duration("age", time.Since(meta.createdAt)),
+			zap.String("stack", meta.stack),
+		)
+	}
+}
+
+// active 会话是否仍在使用：处于事务中或有正在执行的 SQL
+func (m *sessionMeta) active() bool {
+	return m.inTx.Load() || m.running.Load() > 0
+}
+
+// sessionMetaFromContext 从 SQL 钩子的 context 中获取会话附加信息
+func sessionMetaFromContext(ctx context.Context) (*sessionMeta, bool) {
+	meta, ok := ctx.Value(sessionMetaKey{}).(*sessionMeta)
+	return meta, ok
+}
+
+// lookupSession 获取会话的附加信息
+func lookupSession(session *xorm.Session) (*sessionMeta, bool) {
+	v, ok := sessions.Load(weak.Make(session))
+	if !ok {
+		return nil, false
+	}
+	return v.(*sessionMeta), true
+}
+
+// sessionHook 维护会话的事务状态与正在执行的 SQL 数，需最后安装，保证 BeforeProcess 执行后一定会执行 AfterProcess
+type sessionHook struct{}
+
+func (h *sessionHook) BeforeProcess(c *contexts.ContextHook) (context.Context, error) {
+	if meta, ok := sessionMetaFromContext(c.Ctx); ok {
+		meta.running.Add(1)
+	}
+	return c.Ctx, nil
+}
+
+func (h *sessionHook) AfterProcess(c *contexts.ContextHook) error {
+	meta, ok := sessionMetaFromContext(c.Ctx)
+	if !ok {
+		return nil
+	}
+	meta.running.Add(-1)
+	switch c.SQL {
+	case "BEGIN TRANSACTION":
+		meta.inTx.Store(c.Err == nil)
+	case "COMMIT", "ROLLBACK":
+		meta.inTx.Store(false)
+	}
+	return nil
+}
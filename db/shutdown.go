@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultShutdownTimeout ShutdownXorm 等待进行中会话的默认时间
+const DefaultShutdownTimeout = 30 * time.Second
+
+// ErrShutdown 数据库正在关闭或已关闭，不再创建新会话
+var ErrShutdown = errors.New("database is shutting down")
+
+var (
+	closing      atomic.Bool   // 是否正在关闭或已关闭
+	lifecycleMu  sync.RWMutex  // 保护数据库注册表，保证关闭开始后不再登记新会话
+	shuttingDown int           // 进行中的关闭次数，期间拒绝注册
+	healthStop   chan struct{} // 停止健康检查协程
+)
+
+// startHealthCheck 启动定时健康检查协程（只启动一次）
+func startHealthCheck(sqlLog *zap.Logger) {
+	lifecycleMu.Lock()
+	defer lifecycleMu.Unlock()
+	if healthStop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	healthStop = stop
+
+	go func() {
+		ticker := time.NewTicker(time.Hour * 5)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				for _, v := range engineGroups() {
+					if err := v.Ping(); err != nil {
+						sqlLog.Error("mysql ticker ping database fail", zap.Error(err))
+						return
+					}
+				}
+			}
+		}
+	}()
+}
+
+// ShutdownXormContext 优雅关闭所有数据库：
+// 立即拒绝新会话（返回 ErrShutdown），等待进行中的 SQL 和事务结束，
+// ctx 到期时取消剩余会话并强制关闭，返回每个关闭失败的数据库的汇总错误
+func ShutdownXormContext(ctx context.Context) error {
+	lifecycleMu.Lock()
+	closing.Store(true)
+	shuttingDown++
+	if healthStop != nil {
+		close(healthStop)
+		healthStop = nil
+	}
+	lifecycleMu.Unlock()
+	DisableSessionTracking()
+
+	if err := waitSessions(ctx); err != nil && sqlLogger != nil {
+		sqlLogger.Warn("等待数据库会话结束超时，强制关闭",
+			zap.Int("sessions", countSessions()),
+			zap.Error(err),
+		)
+	}
+
+	lifecycleMu.Lock()
+	defer lifecycleMu.Unlock()
+
+	var errs []error
+	for name, g := range dbMgr {
+		if err := g.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("database [%s] close failed: %w", name, err))
+		}
+	}
+	clear(dbMgr)
+	clear(cfgMgr)
+	clear(breakerMgr)
+	readOnlyMgr.Clear()
+	shuttingDown--
+	return errors.Join(errs...)
+}
+
+// waitSessions 等待所有会话的事务与 SQL 结束，ctx 到期时取消剩余会话
+func waitSessions(ctx context.Context) error {
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	for countSessions() > 0 {
+		select {
+		case <-ctx.Done():
+			sessions.Range(func(_, v any) bool {
+				v.(*sessionMeta).cancel()
+				return true
+			})
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// countSessions 返回仍在使用（处于事务中或正在执行 SQL）的会话数，空闲的会话不阻塞关闭
+func countSessions() int {
+	n := 0
+	sessions.Range(func(_, v any) bool {
+		if v.(*sessionMeta).active() {
+			n++
+		}
+		return true
+	})
+	return n
+}
@@ -0,0 +1,263 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"xorm.io/xorm"
+)
+
+// Snowflake ID 结构：41 位毫秒时间戳 | 10 位机器号 | 12 位序列号
+const (
+	workerIDBits = 10
+	sequenceBits = 12
+
+	MaxWorkerID  = 1<<workerIDBits - 1
+	maxSequence  = 1<<sequenceBits - 1
+	workerShift  = sequenceBits
+	timeShift    = sequenceBits + workerIDBits
+	maxBackwards = 5 * time.Millisecond // 可等待的最大时钟回拨
+)
+
+// SnowflakeEpoch Snowflake 时间戳起点（2024-01-01 00:00:00 UTC）
+var SnowflakeEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var (
+	// ErrClockBackwards 时钟回拨超过允许范围
+	ErrClockBackwards = errors.New("snowflake: clock moved backwards")
+	// ErrLeaseLost 机器号租约已丢失，继续生成可能产生重复 ID
+	ErrLeaseLost = errors.New("snowflake: worker id lease lost")
+	// ErrNoWorkerID 没有可用的机器号
+	ErrNoWorkerID = errors.New("snowflake: no available worker id")
+)
+
+// Snowflake ID 生成器
+type Snowflake struct {
+	mu       sync.Mutex
+	workerID int64
+	lastTime int64 // 上次生成 ID 的毫秒时间（相对 SnowflakeEpoch）
+	sequence int64
+
+	lease *workerLease // 机器号租约，手动指定机器号时为 nil
+}
+
+// NewSnowflake 使用指定的机器号创建生成器
+func NewSnowflake(workerID int64) (*Snowflake, error) {
+	if workerID < 0 || workerID > MaxWorkerID {
+		return nil, fmt.Errorf("snowflake: worker id must be between 0 and %d", MaxWorkerID)
+	}
+	return &Snowflake{workerID: workerID}, nil
+}
+
+// WorkerID 返回机器号
+func (s *Snowflake) WorkerID() int64 {
+	return s.workerID
+}
+
+// NextID 生成下一个 ID
+func (s *Snowflake) NextID() (int64, error) {
+	if s.lease != nil && s.lease.lost() {
+		return 0, ErrLeaseLost
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Since(SnowflakeEpoch).Milliseconds()
+	if now < s.lastTime {
+		// 小幅回拨时等待时钟追上
+		if time.Duration(s.lastTime-now)*time.Millisecond > maxBackwards {
+			return 0, ErrClockBackwards
+		}
+		time.Sleep(time.Duration(s.lastTime-now) * time.Millisecond)
+		now = time.Since(SnowflakeEpoch).Milliseconds()
+	}
+
+	if now == s.lastTime {
+		s.sequence = (s.sequence + 1) & maxSequence
+		if s.sequence == 0 {
+			// 当前毫秒序列号用尽，等待下一毫秒
+			for now <= s.lastTime {
+				time.Sleep(100 * time.Microsecond)
+				now = time.Since(SnowflakeEpoch).Milliseconds()
+			}
+		}
+	} else {
+		s.sequence = 0
+	}
+	s.lastTime = now
+
+	return now<<timeShift | s.workerID<<workerShift | s.sequence, nil
+}
+
+// ParseSnowflake 解析 ID 的生成时间、机器号和序列号
+func ParseSnowflake(id int64) (t time.Time, workerID, sequence int64) {
+	t = SnowflakeEpoch.Add(time.Duration(id>>timeShift) * time.Millisecond)
+	workerID = id >> workerShift & MaxWorkerID
+	sequence = id & maxSequence
+	return
+}
+
+// Close 释放机器号租约
+func (s *Snowflake) Close(ctx context.Context) error {
+	if s.lease == nil {
+		return nil
+	}
+	return s.lease.release(ctx)
+}
+
+// workerLease 机器号租约，保存在号段表中（biz_tag 为 snowflake:worker:<id>，max_id 为到期时间毫秒数，description 为持有者）
+type workerLease struct {
+	name     string
+	workerID int64
+	owner    string
+	ttl      time.Duration
+	stop     chan struct{}
+	stopOnce sync.Once
+	mu       sync.Mutex
+	expireAt time.Time
+}
+
+// NewLeasedSnowflake 从名为 name 的数据库号段表中租用一个机器号并创建生成器，
+// 后台每 ttl/3 续约一次，租约丢失后 NextID 返回 ErrLeaseLost；ttl 不能小于 1 秒
+func NewLeasedSnowflake(ctx context.Context, name string, ttl time.Duration) (*Snowflake, error) {
+	if ttl < time.Second {
+		return nil, fmt.Errorf("snowflake lease ttl must be at least 1s: %s", ttl)
+	}
+	lease := &workerLease{name: name, owner: leaseOwner(), ttl: ttl, stop: make(chan struct{})}
+	if err := lease.acquire(ctx); err != nil {
+		return nil, err
+	}
+	go lease.keepAlive()
+	return &Snowflake{workerID: lease.workerID, lease: lease}, nil
+}
+
+// workerTag 机器号在号段表中的业务标识
+func workerTag(id int64) string {
+	return fmt.Sprintf("snowflake:worker:%d", id)
+}
+
+// acquire 依次尝试租用空闲或已过期的机器号，租用在独立事务中提交，不加入调用方的事务
+func (l *workerLease) acquire(ctx context.Context) error {
+	ctx = detachSession(ctx, l.name)
+	var lastErr error
+	for id := int64(0); id <= MaxWorkerID; id++ {
+		now := time.Now()
+		expireAt := now.Add(l.ttl)
+		var ok bool
+		err := Transaction(ctx, l.name, func(session *xorm.Session) error {
+			affected, err := session.Exec("UPDATE "+IDSegment{}.TableName()+" SET max_id = ?, description = ?, update_time = ? WHERE biz_tag = ? AND (max_id < ? OR description = ?)",
+				expireAt.UnixMilli(), l.owner, now, workerTag(id), now.UnixMilli(), l.owner)
+			if err != nil {
+				return err
+			}
+			if n, _ := affected.RowsAffected(); n > 0 {
+				ok = true
+				return nil
+			}
+			has, err := session.Where("biz_tag = ?", workerTag(id)).Exist(new(IDSegment))
+			if err != nil || has {
+				return err
+			}
+			_, err = session.Insert(&IDSegment{BizTag: workerTag(id), MaxId: expireAt.UnixMilli(), Description: l.owner})
+			ok = err == nil
+			return err
+		})
+		if err != nil {
+			// 并发插入同一机器号时继续尝试下一个
+			if ctx.Err() != nil {
+				return err
+			}
+			lastErr = err
+			continue
+		}
+		if ok {
+			l.workerID = id
+			l.expireAt = expireAt
+			return nil
+		}
+	}
+	if lastErr != nil {
+		return fmt.Errorf("snowflake: acquire worker id: %w", lastErr)
+	}
+	return ErrNoWorkerID
+}
+
+// keepAlive 定期续约
+func (l *workerLease) keepAlive() {
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			if err := l.renew(); err != nil && sqlLogger != nil {
+				sqlLogger.Error("Snowflake机器号续约失败", zap.Int64("worker_id", l.workerID), zap.Error(err))
+			}
+		}
+	}
+}
+
+// renew 续约，仅当仍为持有者时成功
+func (l *workerLease) renew() error {
+	ctx, cancel := context.WithTimeout(context.Background(), l.ttl/3)
+	defer cancel()
+
+	now := time.Now()
+	expireAt := now.Add(l.ttl)
+	session, err := NewSessionContext(ctx, l.name)
+	if err != nil {
+		return err
+	}
+	defer Close(session)
+
+	result, err := session.Exec("UPDATE "+IDSegment{}.TableName()+" SET max_id = ?, update_time = ? WHERE biz_tag = ? AND description = ?",
+		expireAt.UnixMilli(), now, workerTag(l.workerID), l.owner)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrLeaseLost
+	}
+	l.mu.Lock()
+	l.expireAt = expireAt
+	l.mu.Unlock()
+	return nil
+}
+
+// lost 租约是否已过期
+func (l *workerLease) lost() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return time.Now().After(l.expireAt)
+}
+
+// release 停止续约并释放机器号，可重复调用
+func (l *workerLease) release(ctx context.Context) error {
+	l.stopOnce.Do(func() { close(l.stop) })
+	session, err := NewSessionContext(ctx, l.name)
+	if err != nil {
+		return err
+	}
+	defer Close(session)
+
+	_, err = session.Exec("UPDATE "+IDSegment{}.TableName()+" SET max_id = 0 WHERE biz_tag = ? AND description = ?",
+		workerTag(l.workerID), l.owner)
+	return err
+}
+
+// leaseOwner 生成租约持有者标识：主机名-进程号-随机数
+func leaseOwner() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b))
+}
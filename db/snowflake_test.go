@@ -0,0 +1,42 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSnowflake(t *testing.T) {
+	s, err := NewSnowflake(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[int64]struct{}, 10000)
+	var last int64
+	for i := 0; i < 10000; i++ {
+		id, err := s.NextID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id <= last {
+			t.Fatalf("id %d not increasing after %d", id, last)
+		}
+		if _, ok := seen[id]; ok {
+			t.Fatalf("duplicate id %d", id)
+		}
+		seen[id] = struct{}{}
+		last = id
+	}
+
+	ts, workerID, _ := ParseSnowflake(last)
+	if workerID != 7 {
+		t.Fatalf("worker id = %d, want 7", workerID)
+	}
+	if d := time.Since(ts); d < 0 || d > time.Second {
+		t.Fatalf("unexpected timestamp %v", ts)
+	}
+
+	if _, err := NewSnowflake(MaxWorkerID + 1); err == nil {
+		t.Fatal("expected error for invalid worker id")
+	}
+}
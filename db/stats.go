@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// PoolStats 单个数据库（主从）的连接池统计
+type PoolStats struct {
+	Master sql.DBStats   `json:"master"` // 主库连接池统计
+	Slaves []sql.DBStats `json:"slaves"` // 从库连接池统计，顺序与配置一致
+}
+
+// Stats 返回所有数据库的连接池统计
+func Stats() map[string]PoolStats {
+	groups := engineGroups()
+	result := make(map[string]PoolStats, len(groups))
+	for name, g := range groups {
+		stats := PoolStats{
+			Master: g.Master().DB().Stats(),
+			Slaves: make([]sql.DBStats, 0, len(g.Slaves())),
+		}
+		for _, s := range g.Slaves() {
+			stats.Slaves = append(stats.Slaves, s.DB().Stats())
+		}
+		result[name] = stats
+	}
+	return result
+}
+
+// DefaultStatsInterval ReportStats 的默认输出间隔
+const DefaultStatsInterval = time.Minute
+
+// ReportStats 按指定间隔将连接池统计输出到 SQL 日志，ctx 取消后停止，interval 小于等于 0 时使用 DefaultStatsInterval
+func ReportStats(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultStatsInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for name, stats := range Stats() {
+					logDBStats(name, "master", stats.Master)
+					for _, s := range stats.Slaves {
+						logDBStats(name, "slave", s)
+					}
+				}
+			}
+		}
+	}()
+}
+
+// logDBStats 输出单个连接池的统计信息
+func logDBStats(name, role string, s sql.DBStats) {
+	if sqlLogger == nil {
+		return
+	}
+	sqlLogger.Info("XORM连接池统计",
+		zap.String("name", name),
+		zap.String("role", role),
+		zap.Int("max_open", s.MaxOpenConnections),
+		zap.Int("open", s.OpenConnections),
+		zap.Int("in_use", s.InUse),
+		zap.Int("idle", s.Idle),
+		zap.Int64("wait_count", s.WaitCount),
+		zap.Duration("wait_duration", s.WaitDuration),
+		zap.Int64("max_idle_closed", s.MaxIdleClosed),
+		zap.Int64("max_idle_time_closed", s.MaxIdleTimeClosed),
+		zap.Int64("max_lifetime_closed", s.MaxLifetimeClosed),
+	)
+}
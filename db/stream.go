@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"iter"
+
+	"xorm.io/xorm"
+)
+
+// Rows 流式遍历查询结果，不会一次性加载到内存；session 需预先设置好查询条件，遍历结束后由调用方关闭会话
+//
+//	for row, err := range db.Rows[User](ctx, session.Where("status = ?", 1)) { ... }
+func Rows[T any](ctx context.Context, session *xorm.Session) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		rows, err := session.Rows(new(T))
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			if err = ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+			var row T
+			if err = rows.Scan(&row); err != nil {
+				yield(zero, err)
+				return
+			}
+			if !yield(row, nil) {
+				return
+			}
+		}
+		if err = rows.Err(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
+
+// Batches 流式遍历查询结果，每次返回最多 size 条记录
+func Batches[T any](ctx context.Context, session *xorm.Session, size int) iter.Seq2[[]T, error] {
+	if size <= 0 {
+		size = 1000
+	}
+	return func(yield func([]T, error) bool) {
+		batch := make([]T, 0, size)
+		for row, err := range Rows[T](ctx, session) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			batch = append(batch, row)
+			if len(batch) == size {
+				if !yield(batch, nil) {
+					return
+				}
+				batch = make([]T, 0, size)
+			}
+		}
+		if len(batch) > 0 {
+			yield(batch, nil)
+		}
+	}
+}
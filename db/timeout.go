@@ -0,0 +1,153 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+	"xorm.io/xorm/contexts"
+)
+
+// ErrTimeout 数据库操作超时，可通过 errors.Is 判断
+var ErrTimeout = errors.New("database operation timeout")
+
+// TimeoutError 数据库操作超时错误，包含超时的 SQL
+type TimeoutError struct {
+	Name    string        // 数据库名称
+	SQL     string        // 超时时正在执行的 SQL
+	Timeout time.Duration // 默认超时时间，由调用方 context 控制时为 0
+	Err     error         // 原始错误
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("database [%s] timeout after %s: %s: %v", e.Name, e.Timeout, e.SQL, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// Is 使 errors.Is(err, ErrTimeout) 成立
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+// WrapTimeout 会话因超时失败时，将错误转换为 *TimeoutError，其他错误原样返回
+func WrapTimeout(session *xorm.Session, err error) error {
+	if err == nil {
+		return nil
+	}
+	var te *TimeoutError
+	if errors.As(err, &te) {
+		return err
+	}
+
+	meta, ok := lookupSession(session)
+	expired := ok && errors.Is(meta.ctx.Err(), context.DeadlineExceeded)
+	if !expired && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	te = &TimeoutError{Err: err}
+	te.SQL, _ = session.LastSQL()
+	if ok {
+		te.Name = meta.name
+		te.Timeout = meta.timeout
+	}
+	return te
+}
+
+// statementTimeout 单条 SQL 默认超时
+func (c *XORMConfigLite) statementTimeout() time.Duration {
+	return time.Millisecond * time.Duration(c.StatementTimeout)
+}
+
+// txTimeout 事务默认超时，未配置时使用单条 SQL 默认超时
+func (c *XORMConfigLite) txTimeout() time.Duration {
+	if c.TxTimeout > 0 {
+		return time.Millisecond * time.Duration(c.TxTimeout)
+	}
+	return c.statementTimeout()
+}
+
+// statementCancelKey context 中保存单条 SQL 超时取消函数的 key
+type statementCancelKey struct{}
+
+// statementContext 单条 SQL 的 context，超时后 Err 返回 *TimeoutError，
+// 直接调用 session.Find 等方法时也能通过 errors.Is(err, ErrTimeout) 判断，errors.Is(err, context.DeadlineExceeded) 仍然成立
+type statementContext struct {
+	context.Context
+	name    string
+	sql     string
+	args    []any
+	timeout time.Duration
+}
+
+func (c *statementContext) Err() error {
+	err := c.Context.Err()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	sql, _ := builder.ConvertToBoundSQL(c.sql, c.args)
+	return &TimeoutError{Name: c.name, SQL: sql, Timeout: c.timeout, Err: err}
+}
+
+// isTxControl 判断是否为事务控制语句，BEGIN 返回的 context 会绑定整个事务，不能应用单条 SQL 超时
+func isTxControl(sql string) bool {
+	switch sql {
+	case "BEGIN TRANSACTION", "COMMIT", "ROLLBACK", "PREPARE":
+		return true
+	}
+	return false
+}
+
+// timeoutHook 为每条 SQL 应用默认超时，并记录因超时失败的 SQL
+type timeoutHook struct {
+	name    string
+	timeout time.Duration // 单条 SQL 默认超时
+}
+
+func (h *timeoutHook) BeforeProcess(c *contexts.ContextHook) (context.Context, error) {
+	if h.timeout <= 0 || isTxControl(c.SQL) {
+		return c.Ctx, nil
+	}
+	// 调用方自带截止时间时不覆盖
+	if meta, ok := sessionMetaFromContext(c.Ctx); ok {
+		if meta.limited {
+			return c.Ctx, nil
+		}
+	} else if _, ok = c.Ctx.Deadline(); ok {
+		return c.Ctx, nil
+	}
+
+	ctx, cancel := context.WithTimeout(c.Ctx, h.timeout)
+	ctx = &statementContext{Context: ctx, name: h.name, sql: c.SQL, args: c.Args, timeout: h.timeout}
+	ctx = context.WithValue(ctx, statementCancelKey{}, cancel)
+	// 后续钩子在此基础上派生 context
+	c.Ctx = ctx
+	return ctx, nil
+}
+
+func (h *timeoutHook) AfterProcess(c *contexts.ContextHook) error {
+	// 查询成功时结果集仍在读取，超时覆盖到读取结束，由截止时间或会话关闭释放
+	if cancel, ok := c.Ctx.Value(statementCancelKey{}).(context.CancelFunc); ok && (c.Err != nil || c.Result != nil) {
+		cancel()
+	}
+	if c.Err == nil || sqlLogger == nil {
+		return nil
+	}
+	if errors.Is(c.Err, context.DeadlineExceeded) || errors.Is(c.Ctx.Err(), context.DeadlineExceeded) {
+		sql, _ := builder.ConvertToBoundSQL(c.SQL, c.Args)
+		sqlLogger.Warn("SQL执行超时",
+			zap.String("name", h.name),
+			zap.String("sql", sql),
+			zap.String("latency", c.ExecuteTime.String()),
+			zap.Error(c.Err),
+		)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"fmt"
+	"maps"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -14,9 +15,22 @@ import (
 // 存储所有的数据库引擎组（主从）
 var dbMgr = map[string]*xorm.EngineGroup{}
 
+// 存储所有数据库的配置
+var cfgMgr = map[string]*XORMConfigLite{}
+
+// SQL 日志实例，由 MustBootUpXORM 设置
+var sqlLogger *zap.Logger
+
 // MustBootUpXORM 初始化并启动 XORM 引擎（可支持多个数据库配置）
 func MustBootUpXORM(configs map[string]*XORMConfigLite, sqlLog *zap.Logger, opts ...Option) error {
 	options := newOptions(opts...)
+	sqlLogger = sqlLog
+	if options.audit != nil {
+		auditSink = options.audit
+	}
+	if options.keys != nil {
+		keyProvider = options.keys
+	}
 
 	for name, c := range configs {
 		// 创建主库连接
@@ -61,11 +75,6 @@ func MustBootUpXORM(configs map[string]*XORMConfigLite, sqlLog *zap.Logger, opts
 			return err
 		}
 
-		// 防止重复加载相同名字的数据库连接
-		if _, ok := dbMgr[name]; ok {
-			return fmt.Errorf("database components loaded twice：[%s]", name)
-		}
-
 		// 同步数据库结构（如果设置了同步）
 		if options.sync != nil && c.Synchronization {
 			if err = options.sync(name, db); err != nil {
@@ -74,69 +83,149 @@ func MustBootUpXORM(configs map[string]*XORMConfigLite, sqlLog *zap.Logger, opts
 		}
 
 		// 保存引擎组
-		dbMgr[name] = db
+		if err = Register(name, db, c); err != nil {
+			return err
+		}
 		sqlLog.Info("XORM连接成功", zap.String("name", name))
 	}
 
 	// 定时健康检查（每 5 小时 ping 一次）
-	go func() {
-		ticker := time.NewTicker(time.Hour * 5)
-		for {
-			select {
-			case <-ticker.C:
-				for _, v := range dbMgr {
-					if err := v.Ping(); err != nil {
-						sqlLog.Error("mysql ticker ping database fail", zap.Error(err))
-						return
-					}
-				}
-			}
+	startHealthCheck(sqlLog)
+
+	return nil
+}
+
+// Register 注册已创建的引擎组，并按配置安装与 MustBootUpXORM 相同的钩子、熔断器与查询缓存
+// 主要用于测试或自行创建引擎的场景，名称重复时返回错误，关闭进行中时返回 ErrShutdown
+func Register(name string, db *xorm.EngineGroup, c *XORMConfigLite) error {
+	if c == nil {
+		c = &XORMConfigLite{}
+	}
+	lifecycleMu.Lock()
+	defer lifecycleMu.Unlock()
+	if shuttingDown > 0 {
+		return ErrShutdown
+	}
+	// 关闭完成后所有数据库均已注销，重新注册时恢复创建会话
+	closing.Store(false)
+
+	// 防止重复加载相同名字的数据库连接
+	if _, ok := dbMgr[name]; ok {
+		return fmt.Errorf("database components loaded twice：[%s]", name)
+	}
+
+	// 查询缓存（可选）
+	if c.Cache != nil {
+		if err := setupCache(name, db, c.Cache); err != nil {
+			return err
 		}
-	}()
+	}
+
+	// 死锁与锁等待超时时采集诊断信息
+	if c.Driver == "mysql" && c.diagnoseInterval() > 0 {
+		db.AddHook(&diagnoseHook{name: name, engine: db.Master(), interval: c.diagnoseInterval()})
+	}
+
+	// 只读模式下拒绝写语句（需在熔断器之前，被拒绝的语句不计入熔断统计）
+	db.AddHook(&readOnlyHook{name: name})
 
+	// 熔断器（可选）
+	if c.Breaker != nil {
+		b := newBreaker(name, c.Breaker)
+		db.AddHook(&breakerHook{breaker: b})
+		breakerMgr[name] = b
+	}
+
+	// 单条 SQL 超时并记录超时的 SQL（需在可能拒绝语句的钩子之后，被拒绝时不会创建计时器）
+	db.AddHook(&timeoutHook{name: name, timeout: c.statementTimeout()})
+
+	// 维护会话状态
+	db.AddHook(&sessionHook{})
+
+	dbMgr[name] = db
+	cfgMgr[name] = c
 	return nil
 }
 
-// Transaction 封装事务操作逻辑
+// Unregister 注销并关闭指定数据库，不等待进行中的会话
+func Unregister(name string) error {
+	lifecycleMu.Lock()
+	defer lifecycleMu.Unlock()
+
+	g, ok := dbMgr[name]
+	if !ok {
+		return fmt.Errorf("database does not exist:[%s]", name)
+	}
+	delete(dbMgr, name)
+	delete(cfgMgr, name)
+	delete(breakerMgr, name)
+	readOnlyMgr.Delete(name)
+	return g.Close()
+}
+
+// Transaction 封装事务操作逻辑，context 未设置截止时间时使用配置的事务默认超时
+// 数据库处于只读模式时立即返回 *ReadOnlyError；context 中已有同名数据库的事务会话（见 WithSession）时直接加入该事务
 func Transaction(ctx context.Context, name string, fn func(*xorm.Session) error) (err error) {
-	session, err := NewSessionContext(ctx, name)
+	if err = checkReadOnly(name); err != nil {
+		return err
+	}
+	if outer, ok := SessionFromContext(ctx, name); ok && outer.IsInTx() {
+		return fn(outer)
+	}
+	var timeout time.Duration
+	if c, ok := config(name); ok {
+		timeout = c.txTimeout()
+	}
+	session, err := newSession(ctx, name, timeout)
 	if err != nil {
 		return err
 	}
 	defer Close(session)
 
 	if err = session.Begin(); err != nil {
-		return err
+		return WrapTimeout(session, err)
 	}
 
 	if err = fn(session); err != nil {
+		// 先记录失败的 SQL，再回滚
+		err = WrapTimeout(session, err)
 		_ = session.Rollback()
+		discardAudits(session)
 		return err
 	}
 
-	return session.Commit()
+	if err = session.Commit(); err != nil {
+		discardAudits(session)
+		return WrapTimeout(session, err)
+	}
+
+	// 事务已提交，审计记录写入失败只记录日志，避免调用方重试时重复执行事务
+	if err = flushAudits(session); err != nil && sqlLogger != nil {
+		sqlLogger.Error("事务提交后写入审计记录失败", zap.String("name", name), zap.Error(err))
+	}
+	return nil
 }
 
 // NewSessionContext 获取一个绑定 context 的数据库会话（需手动释放）
+// context 未设置截止时间时，会话中的每条 SQL 使用配置的单条 SQL 默认超时
 func NewSessionContext(ctx context.Context, name string) (*xorm.Session, error) {
-	if g, e := get(name); e == nil {
-		return g.NewSession().Context(ctx), nil
-	} else {
-		return nil, e
-	}
+	return newSession(ctx, name, 0)
 }
 
 // NewSession 获取一个数据库会话（需手动释放）
 func NewSession(name string) (*xorm.Session, error) {
-	if g, e := get(name); e == nil {
-		return g.NewSession(), nil
-	} else {
-		return nil, e
-	}
+	return NewSessionContext(context.Background(), name)
 }
 
 // 获取对应数据库名称的引擎组
 func get(name string) (*xorm.EngineGroup, error) {
+	lifecycleMu.RLock()
+	defer lifecycleMu.RUnlock()
+	return lookup(name)
+}
+
+// lookup 获取对应数据库名称的引擎组，调用方需持有 lifecycleMu
+func lookup(name string) (*xorm.EngineGroup, error) {
 	g, ok := dbMgr[name]
 	if !ok {
 		return nil, fmt.Errorf("database does not exist:[%s]", name)
@@ -144,21 +233,37 @@ func get(name string) (*xorm.EngineGroup, error) {
 	return g, nil
 }
 
+// config 获取对应数据库名称的配置
+func config(name string) (*XORMConfigLite, bool) {
+	lifecycleMu.RLock()
+	defer lifecycleMu.RUnlock()
+	c, ok := cfgMgr[name]
+	return c, ok
+}
+
+// engineGroups 返回所有引擎组的快照，遍历时不持有锁
+func engineGroups() map[string]*xorm.EngineGroup {
+	lifecycleMu.RLock()
+	defer lifecycleMu.RUnlock()
+	return maps.Clone(dbMgr)
+}
+
 // Close 关闭 XORM 会话
 func Close(session *xorm.Session) {
+	dropAudits(session)
+	defer releaseSession(session)
 	if err := session.Close(); err != nil {
 		// 可以添加日志记录
 		return
 	}
 }
 
-// ShutdownXorm 应用退出时关闭所有数据库连接
+// ShutdownXorm 应用退出时关闭所有数据库连接，最多等待 DefaultShutdownTimeout，失败信息记录到 SQL 日志
 func ShutdownXorm() {
-	for _, v := range dbMgr {
-		if err := v.Close(); err != nil {
-			// 可以添加日志记录
-			continue
-		}
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultShutdownTimeout)
+	defer cancel()
+	if err := ShutdownXormContext(ctx); err != nil && sqlLogger != nil {
+		sqlLogger.Error("XORM关闭失败", zap.Error(err))
 	}
 }
 
@@ -167,7 +272,9 @@ type syncFunc func(string, *xorm.EngineGroup) error
 
 // Options 用于配置 BootUp 的可选参数
 type Options struct {
-	sync syncFunc
+	sync  syncFunc
+	audit AuditSink
+	keys  KeyProvider
 }
 
 // Option 是对 Options 的函数式配置
@@ -180,6 +287,20 @@ func SetSyncFunc(f syncFunc) Option {
 	}
 }
 
+// SetAuditSink 设置审计记录的写入目标（数据库表或 Mongo 集合）
+func SetAuditSink(sink AuditSink) Option {
+	return func(o *Options) {
+		o.audit = sink
+	}
+}
+
+// SetKeyProvider 设置字段加密使用的密钥提供者
+func SetKeyProvider(p KeyProvider) Option {
+	return func(o *Options) {
+		o.keys = p
+	}
+}
+
 // 解析所有 Option
 func newOptions(opts ...Option) Options {
 	opt := Options{
@@ -1,23 +1,229 @@
-package db
+package db_test
 
 import (
 	"context"
-	`fmt`
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/restoflife/ql_common/db"
+	"github.com/restoflife/ql_common/db/dbtest"
+	"xorm.io/builder"
 	"xorm.io/xorm"
 )
 
-func TestName(t *testing.T) {
-	err := Transaction(context.Background(), "default", func(session *xorm.Session) error {
+type account struct {
+	Id      int64  `xorm:"pk autoincr"`
+	Name    string `xorm:"varchar(32)"`
+	Balance int64
+}
+
+func TestTransaction(t *testing.T) {
+	dbtest.Open(t, "default", dbtest.WithModels(new(account)))
+	ctx := context.Background()
+
+	err := db.Transaction(ctx, "default", func(session *xorm.Session) error {
 		// 执行事务操作
-		if _, err := session.Insert(map[string]string{}); err != nil {
+		a := &account{Name: "alice", Balance: 100}
+		if _, err := session.Insert(a); err != nil {
 			return err
 		}
-		if _, err := session.Update(map[string]string{}); err != nil {
+		if _, err := session.ID(a.Id).Cols("balance").Update(&account{Balance: 80}); err != nil {
 			return err
 		}
+		if list := db.SessionSnapshot(); len(list) != 1 || !list[0].InTx {
+			t.Errorf("SessionSnapshot = %+v", list)
+		}
 		return nil
 	})
-	fmt.Println(err)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 失败的事务会回滚
+	fail := errors.New("fail")
+	err = db.Transaction(ctx, "default", func(session *xorm.Session) error {
+		if _, err := session.Insert(&account{Name: "bob"}); err != nil {
+			return err
+		}
+		return fail
+	})
+	if !errors.Is(err, fail) {
+		t.Fatalf("Transaction err = %v", err)
+	}
+
+	got, err := db.Find[account](ctx, "default", nil, db.OrderBy("id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Balance != 80 {
+		t.Fatalf("unexpected rows: %+v", got)
+	}
+}
+
+func TestQueryHelpers(t *testing.T) {
+	dbtest.Open(t, "default",
+		dbtest.WithModels(new(account)),
+		dbtest.WithFixtures("testdata/account.yaml"),
+	)
+	ctx := dbtest.Tx(t, context.Background(), "default")
+
+	a, err := db.Get[account](ctx, "default", builder.Eq{"name": "alice"})
+	if err != nil || a.Balance != 100 {
+		t.Fatalf("Get = %+v, %v", a, err)
+	}
+	if _, err = db.Get[account](ctx, "default", builder.Eq{"name": "nobody"}); !errors.Is(err, db.ErrNotFound) {
+		t.Fatalf("Get missing err = %v", err)
+	}
+
+	// 嵌套事务加入 context 中的事务
+	err = db.Transaction(ctx, "default", func(session *xorm.Session) error {
+		_, err := session.Insert(&account{Name: "carol", Balance: 1})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := db.Count[account](ctx, "default", builder.Gt{"balance": 0})
+	if err != nil || n != 3 {
+		t.Fatalf("Count = %d, %v", n, err)
+	}
+	has, err := db.Exists[account](ctx, "default", builder.Eq{"name": "carol"})
+	if err != nil || !has {
+		t.Fatalf("Exists = %v, %v", has, err)
+	}
+	names, err := db.Pluck[account, string](ctx, "default", "name", nil, db.OrderBy("id"))
+	if err != nil || len(names) != 3 || names[2] != "carol" {
+		t.Fatalf("Pluck = %v, %v", names, err)
+	}
+}
+
+func TestTransactionJoinsContextSession(t *testing.T) {
+	g := dbtest.Open(t, "default", dbtest.WithModels(new(account)))
+	outer := g.NewSession()
+	defer outer.Close()
+	if err := outer.Begin(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := db.WithSession(context.Background(), "default", outer)
+
+	// 嵌套事务加入 context 中的事务，而不是新开会话
+	err := db.Transaction(ctx, "default", func(session *xorm.Session) error {
+		if session != outer {
+			t.Fatal("Transaction did not join the outer session")
+		}
+		_, err := session.Insert(&account{Name: "alice"})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := db.Count[account](ctx, "default", nil); err != nil || n != 1 {
+		t.Fatalf("Count in tx = %d, %v", n, err)
+	}
+
+	// 外层回滚时嵌套写入一并回滚
+	if err = outer.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := db.Count[account](context.Background(), "default", nil); err != nil || n != 0 {
+		t.Fatalf("Count after rollback = %d, %v", n, err)
+	}
+}
+
+func TestStatementTimeout(t *testing.T) {
+	dbtest.Open(t, "default", dbtest.WithModels(new(account)),
+		dbtest.WithConfig(&db.XORMConfigLite{StatementTimeout: 100}))
+
+	session, err := db.NewSessionContext(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close(session)
+
+	// 超时只作用于单条 SQL，不限制会话的存活时间
+	time.Sleep(150 * time.Millisecond)
+	if _, err = session.Insert(&account{Name: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	var got []account
+	if err = session.Find(&got); err != nil || len(got) != 1 {
+		t.Fatalf("Find = %v, %v", got, err)
+	}
+
+	// 直接调用会话方法超时时也返回 *TimeoutError
+	_, err = session.QueryString("WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i+1 FROM n) SELECT count(*) FROM n")
+	if !errors.Is(err, db.ErrTimeout) {
+		t.Fatalf("slow query err = %v", err)
+	}
+}
+
+func TestShutdownIgnoresIdleSessions(t *testing.T) {
+	dbtest.Open(t, "default", dbtest.WithModels(new(account)))
+
+	// 直接关闭的会话与空闲会话都不阻塞关闭
+	closed, err := db.NewSession("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = closed.Close()
+	idle, err := db.NewSession("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close(idle)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err = db.ShutdownXormContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.NewSession("default"); !errors.Is(err, db.ErrShutdown) {
+		t.Fatalf("NewSession after shutdown err = %v", err)
+	}
+}
+
+func TestRegisterDuringShutdown(t *testing.T) {
+	g := dbtest.Open(t, "default", dbtest.WithModels(new(account)))
+	session, err := db.NewSession("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = session.Begin(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 事务未结束时关闭一直等待，期间拒绝注册
+	done := make(chan error, 1)
+	go func() { done <- db.ShutdownXormContext(context.Background()) }()
+	for {
+		s, err := db.NewSession("default")
+		if errors.Is(err, db.ErrShutdown) {
+			break
+		}
+		if err == nil {
+			db.Close(s)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err = db.Register("other", g, nil); !errors.Is(err, db.ErrShutdown) {
+		t.Fatalf("Register during shutdown err = %v", err)
+	}
+	// 关闭等待期间读取注册表不会与关闭冲突
+	if stats := db.Stats(); len(stats) != 1 {
+		t.Fatalf("Stats = %v", stats)
+	}
+
+	_ = session.Rollback()
+	db.Close(session)
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// 关闭完成后可以重新注册
+	dbtest.Open(t, "default", dbtest.WithModels(new(account)))
+	if _, err = db.NewSession("default"); err != nil {
+		t.Fatalf("NewSession after re-register err = %v", err)
+	}
 }
@@ -0,0 +1,27 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// fieldsKey context 中保存日志字段的 key
+type fieldsKey struct{}
+
+// WithFields 在 context 中附加日志字段（如请求 ID、用户 ID），下游组件记录日志时会带上这些字段
+func WithFields(ctx context.Context, fields ...zap.Field) context.Context {
+	old := FieldsFromContext(ctx)
+	merged := make([]zap.Field, 0, len(old)+len(fields))
+	merged = append(append(merged, old...), fields...)
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// FieldsFromContext 获取 context 中附加的日志字段
+func FieldsFromContext(ctx context.Context) []zap.Field {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey{}).([]zap.Field)
+	return fields
+}
@@ -0,0 +1,116 @@
+package logger
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RateLimitResult 限流判定结果
+type RateLimitResult struct {
+	Allowed    bool          // 是否放行
+	Limit      int64         // 配额上限（窗口内请求数或突发容量）
+	Remaining  int64         // 剩余配额
+	RetryAfter time.Duration // 被拒绝时需要等待的时间
+	ResetAfter time.Duration // 配额恢复的时间
+}
+
+// RateLimiter 限流器，redis 包中的各类限流器均实现该接口
+type RateLimiter interface {
+	Allow(ctx context.Context, key string) (*RateLimitResult, error)
+}
+
+// RateLimitKeyFunc 从请求中提取限流 key，返回空字符串时不限流
+type RateLimitKeyFunc func(c *gin.Context) string
+
+// KeyByIP 按客户端 IP 限流
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByRoute 按路由限流（所有客户端共享配额）
+func KeyByRoute(c *gin.Context) string {
+	return "route:" + c.Request.Method + ":" + c.FullPath()
+}
+
+// KeyByRouteAndIP 按路由和客户端 IP 限流
+func KeyByRouteAndIP(c *gin.Context) string {
+	return "route:" + c.Request.Method + ":" + c.FullPath() + ":ip:" + c.ClientIP()
+}
+
+// KeyByUser 按上下文中的用户标识（c.Set(key, userID)）限流，未登录时按 IP 限流
+func KeyByUser(key string) RateLimitKeyFunc {
+	return func(c *gin.Context) string {
+		if v, ok := c.Get(key); ok {
+			if s := toString(v); s != "" {
+				return "user:" + s
+			}
+		}
+		return KeyByIP(c)
+	}
+}
+
+// RateLimit 限流中间件：设置 RateLimit-Limit/Remaining/Reset 响应头，超限时返回 429 与 Retry-After
+// 限流器出错（如 Redis 不可用）时放行请求并记录日志
+//
+//	limiter := redis.Use("default").NewGCRALimiter("api", 100, time.Second, 20)
+//	r.Use(logger.RateLimit(log, limiter, logger.KeyByIP))
+func RateLimit(log *zap.Logger, limiter RateLimiter, keyFunc RateLimitKeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := keyFunc(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		res, err := limiter.Allow(c.Request.Context(), key)
+		if err != nil {
+			log.Error("[rate limit]",
+				zap.String("path", c.Request.URL.Path),
+				zap.String("key", key),
+				zap.Error(err),
+			)
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Set("RateLimit-Limit", strconv.FormatInt(res.Limit, 10))
+		h.Set("RateLimit-Remaining", strconv.FormatInt(max(res.Remaining, 0), 10))
+		h.Set("RateLimit-Reset", ceilSeconds(res.ResetAfter))
+		if !res.Allowed {
+			h.Set("Retry-After", ceilSeconds(res.RetryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"code":    http.StatusTooManyRequests,
+				"message": "Too many requests",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// ceilSeconds 将时长向上取整为秒
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
+
+// toString 将用户标识转换为字符串
+func toString(v any) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case int:
+		return strconv.Itoa(s)
+	case int64:
+		return strconv.FormatInt(s, 10)
+	case uint64:
+		return strconv.FormatUint(s, 10)
+	}
+	return ""
+}
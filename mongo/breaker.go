@@ -0,0 +1,62 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/restoflife/ql_common/breaker"
+	"github.com/restoflife/ql_common/logger"
+	"go.mongodb.org/mongo-driver/v2/event"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.uber.org/zap"
+)
+
+// ErrCircuitOpen Mongo 熔断器打开时返回的错误
+var ErrCircuitOpen = breaker.ErrCircuitOpen
+
+// 存储每个 Mongo 实例的熔断器（未配置时不存在）
+var breakerMgr = map[string]*breaker.Breaker{}
+
+// BreakerState 返回 Mongo 实例熔断器的当前状态，未配置熔断器时返回 false
+func BreakerState(name string) (breaker.State, bool) {
+	mu.RLock()
+	b, ok := breakerMgr[name]
+	mu.RUnlock()
+	if !ok {
+		return breaker.StateClosed, false
+	}
+	return b.State(), true
+}
+
+// newBreaker 创建 Mongo 熔断器，状态变化记录到日志
+func newBreaker(name string, c *breaker.Config) *breaker.Breaker {
+	return breaker.New(name, c, func(name string, from, to breaker.State) {
+		logger.Warn("Mongo熔断器状态变化",
+			zap.String("name", name),
+			zap.String("from", from.String()),
+			zap.String("to", to.String()),
+		)
+	})
+}
+
+// breakerMonitor 包装命令监视器，根据命令结果更新熔断器
+// 驱动事件无法与请求一一对应，半开状态下不限制探测请求数
+func breakerMonitor(b *breaker.Breaker, next *event.CommandMonitor) *event.CommandMonitor {
+	m := &event.CommandMonitor{
+		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+			b.Report(true)
+			if next != nil && next.Succeeded != nil {
+				next.Succeeded(ctx, evt)
+			}
+		},
+		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+			b.Report(!mongo.IsNetworkError(evt.Failure) && !mongo.IsTimeout(evt.Failure))
+			if next != nil && next.Failed != nil {
+				next.Failed(ctx, evt)
+			}
+		},
+	}
+	if next != nil {
+		m.Started = next.Started
+	}
+	return m
+}
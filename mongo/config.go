@@ -1,5 +1,7 @@
 package mongo
 
+import "github.com/restoflife/ql_common/breaker"
+
 type Config struct {
 	URI         string `toml:"uri" yaml:"uri" json:"uri"`                               // MongoDB 连接 URI，例如：mongodb://127.0.0.1:27017
 	MaxPoolSize uint64 `toml:"max_pool_size" yaml:"max_pool_size" json:"max_pool_size"` // 最大连接池大小（单位：连接数）
@@ -9,4 +11,6 @@ type Config struct {
 	Password    string `toml:"password" yaml:"password" json:"password"`                // 密码（与用户名配合使用）
 	Database    string `toml:"database" yaml:"database" json:"database"`                // 默认数据库名称（如 admin、test、your_db_name）
 	AuthSource  string `toml:"auth_source" yaml:"auth_source" json:"auth_source"`       // 认证数据库名（用户名密码验证时使用）
+
+	Breaker *breaker.Config `toml:"breaker" yaml:"breaker" json:"breaker"` // 熔断器配置（可选），为空时不启用
 }
@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/restoflife/ql_common/breaker"
 	"github.com/restoflife/ql_common/logger"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
@@ -64,6 +65,17 @@ func MustBootUpMongo(configs map[string]*Config, mongoLog *zap.Logger) error {
 				clientOpts.SetMinPoolSize(cfg.MinPoolSize)
 			}
 
+			// 记录连接池事件，供 Stats 查询
+			monitor, counter := newPoolMonitor()
+			clientOpts.SetPoolMonitor(monitor)
+
+			// 熔断器（可选）
+			var b *breaker.Breaker
+			if cfg.Breaker != nil {
+				b = newBreaker(name, cfg.Breaker)
+				clientOpts.SetMonitor(breakerMonitor(b, clientOpts.Monitor))
+			}
+
 			client, err := mongo.Connect(clientOpts)
 			if err != nil {
 				return fmt.Errorf("mongo [%s] 连接失败：%w", name, err)
@@ -84,9 +96,14 @@ func MustBootUpMongo(configs map[string]*Config, mongoLog *zap.Logger) error {
 			mu.Lock()
 			defer mu.Unlock()
 			if _, ok := clientMap[name]; ok {
+				_ = client.Disconnect(ctx)
 				return fmt.Errorf("mongo [%s] 已存在", name)
 			}
 			clientMap[name] = client
+			poolCounters[name] = counter
+			if b != nil {
+				breakerMgr[name] = b
+			}
 
 			logger.Info("Mongo连接成功", zap.String("name", name), zap.String("uri", cfg.URI))
 			return nil
@@ -427,6 +444,12 @@ func GetClient(name string) (*mongo.Client, error) {
 	if !ok {
 		return nil, fmt.Errorf("mongo实例 [%s] 不存在", name)
 	}
+	// 熔断器打开时快速失败
+	if b, ok := breakerMgr[name]; ok {
+		if err := b.Check(); err != nil {
+			return nil, err
+		}
+	}
 	return client, nil
 }
 
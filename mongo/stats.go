@@ -0,0 +1,108 @@
+package mongo
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/restoflife/ql_common/logger"
+	"go.mongodb.org/mongo-driver/v2/event"
+	"go.uber.org/zap"
+)
+
+// PoolStats Mongo 连接池统计（基于 PoolMonitor 事件累计）
+type PoolStats struct {
+	Created        int64 `json:"created"`          // 累计创建的连接数
+	Closed         int64 `json:"closed"`           // 累计关闭的连接数
+	CheckedOut     int64 `json:"checked_out"`      // 累计借出次数
+	CheckedIn      int64 `json:"checked_in"`       // 累计归还次数
+	CheckOutFailed int64 `json:"check_out_failed"` // 累计借出失败次数
+	Cleared        int64 `json:"cleared"`          // 连接池被清空的次数
+	Open           int64 `json:"open"`             // 当前打开的连接数
+	InUse          int64 `json:"in_use"`           // 当前使用中的连接数
+}
+
+// poolCounter 连接池事件计数器
+type poolCounter struct {
+	created, closed, checkedOut, checkedIn, checkOutFailed, cleared atomic.Int64
+}
+
+// 每个 Mongo 实例的连接池计数器
+var poolCounters = map[string]*poolCounter{}
+
+// newPoolMonitor 创建连接池监视器，记录连接池事件；计数器需在客户端注册成功后放入 poolCounters
+func newPoolMonitor() (*event.PoolMonitor, *poolCounter) {
+	c := &poolCounter{}
+	return &event.PoolMonitor{
+		Event: func(evt *event.PoolEvent) {
+			switch evt.Type {
+			case event.ConnectionCreated:
+				c.created.Add(1)
+			case event.ConnectionClosed:
+				c.closed.Add(1)
+			case event.ConnectionCheckedOut:
+				c.checkedOut.Add(1)
+			case event.ConnectionCheckedIn:
+				c.checkedIn.Add(1)
+			case event.ConnectionCheckOutFailed:
+				c.checkOutFailed.Add(1)
+			case event.ConnectionPoolCleared:
+				c.cleared.Add(1)
+			}
+		},
+	}, c
+}
+
+// Stats 返回所有 Mongo 实例的连接池统计
+func Stats() map[string]PoolStats {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	result := make(map[string]PoolStats, len(poolCounters))
+	for name, c := range poolCounters {
+		s := PoolStats{
+			Created:        c.created.Load(),
+			Closed:         c.closed.Load(),
+			CheckedOut:     c.checkedOut.Load(),
+			CheckedIn:      c.checkedIn.Load(),
+			CheckOutFailed: c.checkOutFailed.Load(),
+			Cleared:        c.cleared.Load(),
+		}
+		s.Open = s.Created - s.Closed
+		s.InUse = s.CheckedOut - s.CheckedIn
+		result[name] = s
+	}
+	return result
+}
+
+// DefaultStatsInterval ReportStats 的默认输出间隔
+const DefaultStatsInterval = time.Minute
+
+// ReportStats 按指定间隔输出 Mongo 连接池统计，ctx 取消后停止，interval 小于等于 0 时使用 DefaultStatsInterval
+func ReportStats(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultStatsInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for name, s := range Stats() {
+					logger.Info("Mongo连接池统计",
+						zap.String("name", name),
+						zap.Int64("open", s.Open),
+						zap.Int64("in_use", s.InUse),
+						zap.Int64("created", s.Created),
+						zap.Int64("closed", s.Closed),
+						zap.Int64("check_out_failed", s.CheckOutFailed),
+						zap.Int64("cleared", s.Cleared),
+					)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,71 @@
+package redis
+
+import (
+	"context"
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/restoflife/ql_common/breaker"
+	"github.com/restoflife/ql_common/logger"
+	"go.uber.org/zap"
+)
+
+// ErrCircuitOpen Redis 熔断器打开时返回的错误
+var ErrCircuitOpen = breaker.ErrCircuitOpen
+
+// 存储每个 Redis 实例的熔断器（未配置时不存在）
+var breakerMgr = map[string]*breaker.Breaker{}
+
+// BreakerState 返回 Redis 实例熔断器的当前状态，未配置熔断器时返回 false
+func BreakerState(name string) (breaker.State, bool) {
+	b, ok := breakerMgr[name]
+	if !ok {
+		return breaker.StateClosed, false
+	}
+	return b.State(), true
+}
+
+// newBreaker 创建 Redis 熔断器，状态变化记录到日志
+func newBreaker(name string, c *breaker.Config) *breaker.Breaker {
+	return breaker.New(name, c, func(name string, from, to breaker.State) {
+		logger.Warn("Redis熔断器状态变化",
+			zap.String("name", name),
+			zap.String("from", from.String()),
+			zap.String("to", to.String()),
+		)
+	})
+}
+
+// breakerHook 在每个命令执行前后更新熔断器
+type breakerHook struct {
+	breaker *breaker.Breaker
+}
+
+func (h *breakerHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *breakerHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		return h.breaker.Execute(func() error {
+			return next(ctx, cmd)
+		}, isBreakerFailure)
+	}
+}
+
+func (h *breakerHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		return h.breaker.Execute(func() error {
+			return next(ctx, cmds)
+		}, isBreakerFailure)
+	}
+}
+
+// isBreakerFailure 判断错误是否代表 Redis 不可用（空值和服务端返回的错误不计入失败）
+func isBreakerFailure(err error) bool {
+	if errors.Is(err, redis.Nil) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var re redis.Error
+	return !errors.As(err, &re)
+}
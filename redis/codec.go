@@ -0,0 +1,286 @@
+package redis
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// DefaultCompressThreshold 编码后超过该字节数的值会被压缩
+const DefaultCompressThreshold = 1024
+
+// 值的头部为 headerMagic 加一个格式字节，无头部的旧值按原始编码解析
+// 0xC1 在 msgpack 中从未使用，也不会出现在 JSON 文本的开头，因此不会与任何编码的数据混淆
+const (
+	headerMagic byte = 0xC1
+	headerPlain byte = 0x00 // 未压缩
+	headerGzip  byte = 0x01 // gzip 压缩
+)
+
+// Codec 对象的序列化方式
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec JSON 编码（默认）
+var JSONCodec Codec = jsonCodec{}
+
+// MsgpackCodec msgpack 编码，体积更小、速度更快，但数据不可直接阅读
+var MsgpackCodec Codec = msgpackCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v any) ([]byte, error)      { return msgpack.Marshal(v) }
+func (msgpackCodec) Unmarshal(data []byte, v any) error { return msgpack.Unmarshal(data, v) }
+
+// 默认编码，通过 SetDefaultCodec 修改
+var defaultCodec = JSONCodec
+
+// SetDefaultCodec 设置 SetObj、GetObj 等方法及 GetOrLoad 默认使用的编码，需在启动时调用
+func SetDefaultCodec(c Codec) {
+	defaultCodec = c
+}
+
+// codecOptions 对象读写的可选参数
+type codecOptions struct {
+	codec     Codec
+	threshold int
+}
+
+// CodecOption 是对对象读写的函数式配置
+type CodecOption func(*codecOptions)
+
+// WithCodec 指定本次读写使用的编码，读写同一 key 时需保持一致
+func WithCodec(c Codec) CodecOption {
+	return func(o *codecOptions) {
+		o.codec = c
+	}
+}
+
+// WithCompressThreshold 设置压缩阈值（默认 1024 字节），小于 0 时不压缩；读取时自动识别，无需设置
+func WithCompressThreshold(n int) CodecOption {
+	return func(o *codecOptions) {
+		o.threshold = n
+	}
+}
+
+func newCodecOptions(opts ...CodecOption) codecOptions {
+	o := codecOptions{codec: defaultCodec, threshold: DefaultCompressThreshold}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+var (
+	gzipWriters sync.Pool
+	gzipReaders sync.Pool
+)
+
+// encode 编码并添加头字节，超过阈值时压缩
+func (o codecOptions) encode(v any) ([]byte, error) {
+	data, err := o.codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if o.threshold < 0 || len(data) <= o.threshold {
+		return append([]byte{headerMagic, headerPlain}, data...), nil
+	}
+
+	var buf bytes.Buffer
+	buf.Write([]byte{headerMagic, headerGzip})
+	w, _ := gzipWriters.Get().(*gzip.Writer)
+	if w == nil {
+		w = gzip.NewWriter(&buf)
+	} else {
+		w.Reset(&buf)
+	}
+	defer gzipWriters.Put(w)
+	if _, err = w.Write(data); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decode 按头部解压并解码，无头部时按旧格式直接解码
+func (o codecOptions) decode(data []byte, v any) error {
+	if len(data) == 0 {
+		return fmt.Errorf("redis: cannot decode empty value")
+	}
+	if data[0] != headerMagic {
+		return o.codec.Unmarshal(data, v)
+	}
+	if len(data) < 2 {
+		return fmt.Errorf("redis: malformed value header")
+	}
+	body := data[2:]
+	switch data[1] {
+	case headerPlain:
+		return o.codec.Unmarshal(body, v)
+	case headerGzip:
+		r, _ := gzipReaders.Get().(*gzip.Reader)
+		var err error
+		if r == nil {
+			r, err = gzip.NewReader(bytes.NewReader(body))
+		} else {
+			err = r.Reset(bytes.NewReader(body))
+		}
+		if err != nil {
+			return err
+		}
+		defer gzipReaders.Put(r)
+		raw, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return o.codec.Unmarshal(raw, v)
+	}
+	return fmt.Errorf("redis: unknown value format 0x%02x", data[1])
+}
+
+// ==================== 对象读写 ====================
+
+// SetObj 编码后写入字符串值
+//
+//	err := redis.SetObj(ctx, "cache", "user:1", user, time.Hour)
+func SetObj[T any](ctx context.Context, name, key string, value T, expiration time.Duration, opts ...CodecOption) error {
+	data, err := newCodecOptions(opts...).encode(value)
+	if err != nil {
+		return err
+	}
+	return Use(name).Set(ctx, key, data, expiration)
+}
+
+// GetObj 读取并解码字符串值，key 不存在时返回 redis.Nil
+//
+//	user, err := redis.GetObj[*User](ctx, "cache", "user:1")
+func GetObj[T any](ctx context.Context, name, key string, opts ...CodecOption) (T, error) {
+	var v T
+	data, err := Use(name).Get(ctx, key)
+	if err != nil {
+		return v, err
+	}
+	err = newCodecOptions(opts...).decode([]byte(data), &v)
+	return v, err
+}
+
+// MGetObj 批量读取并解码，结果只包含存在的 key；集群模式下 key 需位于同一槽位
+func MGetObj[T any](ctx context.Context, name string, keys []string, opts ...CodecOption) (map[string]T, error) {
+	result := make(map[string]T, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+	values, err := Use(name).MGet(ctx, keys...)
+	if err != nil {
+		return nil, err
+	}
+	o := newCodecOptions(opts...)
+	for i, raw := range values {
+		s, ok := raw.(string)
+		if !ok {
+			continue
+		}
+		var v T
+		if err = o.decode([]byte(s), &v); err != nil {
+			return nil, fmt.Errorf("redis: decode %s: %w", keys[i], err)
+		}
+		result[keys[i]] = v
+	}
+	return result, nil
+}
+
+// HSetObj 编码后写入哈希字段
+func HSetObj[T any](ctx context.Context, name, key, field string, value T, opts ...CodecOption) error {
+	data, err := newCodecOptions(opts...).encode(value)
+	if err != nil {
+		return err
+	}
+	return Use(name).HSet(ctx, key, field, data)
+}
+
+// HMSetObj 编码后批量写入哈希字段
+func HMSetObj[T any](ctx context.Context, name, key string, values map[string]T, opts ...CodecOption) error {
+	if len(values) == 0 {
+		return nil
+	}
+	o := newCodecOptions(opts...)
+	args := make(map[string]any, len(values))
+	for field, value := range values {
+		data, err := o.encode(value)
+		if err != nil {
+			return fmt.Errorf("redis: encode %s: %w", field, err)
+		}
+		args[field] = data
+	}
+	return Use(name).HMSet(ctx, key, args)
+}
+
+// HGetObj 读取并解码哈希字段，字段不存在时返回 redis.Nil
+func HGetObj[T any](ctx context.Context, name, key, field string, opts ...CodecOption) (T, error) {
+	var v T
+	data, err := Use(name).HGet(ctx, key, field)
+	if err != nil {
+		return v, err
+	}
+	err = newCodecOptions(opts...).decode([]byte(data), &v)
+	return v, err
+}
+
+// HMGetObj 批量读取并解码哈希字段，结果只包含存在的字段
+func HMGetObj[T any](ctx context.Context, name, key string, fields []string, opts ...CodecOption) (map[string]T, error) {
+	result := make(map[string]T, len(fields))
+	if len(fields) == 0 {
+		return result, nil
+	}
+	values, err := Use(name).HMGet(ctx, key, fields...)
+	if err != nil {
+		return nil, err
+	}
+	o := newCodecOptions(opts...)
+	for i, raw := range values {
+		s, ok := raw.(string)
+		if !ok {
+			continue
+		}
+		var v T
+		if err = o.decode([]byte(s), &v); err != nil {
+			return nil, fmt.Errorf("redis: decode %s: %w", fields[i], err)
+		}
+		result[fields[i]] = v
+	}
+	return result, nil
+}
+
+// HGetAllObj 读取并解码整个哈希
+func HGetAllObj[T any](ctx context.Context, name, key string, opts ...CodecOption) (map[string]T, error) {
+	values, err := Use(name).HGetAll(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	o := newCodecOptions(opts...)
+	result := make(map[string]T, len(values))
+	for field, s := range values {
+		var v T
+		if err = o.decode([]byte(s), &v); err != nil {
+			return nil, fmt.Errorf("redis: decode %s: %w", field, err)
+		}
+		result[field] = v
+	}
+	return result, nil
+}
@@ -0,0 +1,71 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestCodecHeader(t *testing.T) {
+	type item struct {
+		Name string
+		N    int
+	}
+	for _, codec := range []Codec{JSONCodec, MsgpackCodec} {
+		o := newCodecOptions(WithCodec(codec))
+
+		// 无头部的旧值按原始编码解析，包括首字节与旧格式头字节相同的 msgpack 正整数
+		for _, v := range []int{1, 2, 193} {
+			raw, _ := codec.Marshal(v)
+			var got int
+			if err := o.decode(raw, &got); err != nil || got != v {
+				t.Fatalf("%T legacy %d: got %d, %v", codec, v, got, err)
+			}
+		}
+
+		// 压缩与未压缩的新值都可以解析
+		for _, want := range []item{{Name: "a", N: 1}, {Name: strings.Repeat("x", 4096), N: 2}} {
+			data, err := o.encode(want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data[0] != headerMagic {
+				t.Fatalf("%T header = %#x", codec, data[0])
+			}
+			var got item
+			if err = o.decode(data, &got); err != nil || got != want {
+				t.Fatalf("%T decode: %+v, %v", codec, got, err)
+			}
+		}
+	}
+
+	// 任何编码的数据都不会以头部魔数开头
+	if raw, _ := json.Marshal(item{}); raw[0] == headerMagic {
+		t.Fatal("json collides with header")
+	}
+	if raw, _ := msgpack.Marshal(item{}); raw[0] == headerMagic {
+		t.Fatal("msgpack collides with header")
+	}
+}
+
+func TestSetGetObj(t *testing.T) {
+	m := newTestRedis(t)
+	ctx := context.Background()
+
+	if err := SetObj(ctx, "default", "obj", map[string]int{"a": 1}, 0); err != nil {
+		t.Fatal(err)
+	}
+	got, err := GetObj[map[string]int](ctx, "default", "obj")
+	if err != nil || got["a"] != 1 {
+		t.Fatalf("GetObj = %v, %v", got, err)
+	}
+
+	// 升级前写入的原始 JSON 仍可读取
+	_ = m.Set("legacy", `{"a":2}`)
+	if got, err = GetObj[map[string]int](ctx, "default", "legacy"); err != nil || got["a"] != 2 {
+		t.Fatalf("GetObj legacy = %v, %v", got, err)
+	}
+}
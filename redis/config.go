@@ -1,12 +1,136 @@
 package redis
 
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/restoflife/ql_common/breaker"
+)
+
 type Config struct {
-	Mode       string   `toml:"mode" yaml:"mode" json:"mode"`                      // Redis 模式：standalone（单机）、sentinel（哨兵）、cluster（集群）
+	Mode       string   `toml:"mode" yaml:"mode" json:"mode"`                      // Redis 模式：standalone（单机）、sentinel（哨兵）、cluster（集群），为空或未知时按单机模式处理
 	Addr       string   `toml:"addr" yaml:"addr" json:"addr"`                      // Redis 地址，单机模式下使用，例如：127.0.0.1:6379
+	Username   string   `toml:"username" yaml:"username" json:"username"`          // ACL 用户名（Redis 6.0+，可选）
 	Password   string   `toml:"password" yaml:"password" json:"password"`          // Redis 认证密码
 	DB         int      `toml:"db" yaml:"db" json:"db"`                            // Redis 数据库编号（仅单机和哨兵模式有效）
 	MasterName string   `toml:"master_name" yaml:"master_name" json:"master_name"` // 哨兵模式下主节点名称
 	Slaves     []string `toml:"slaves" yaml:"slaves" json:"slaves"`                // 哨兵或集群模式下的节点地址列表
 	PoolSize   int      `toml:"pool_size" yaml:"pool_size" json:"pool_size"`       // 最大连接池大小
 	MinIdle    int      `toml:"min_idle" yaml:"min_idle" json:"min_idle"`          // 最小空闲连接数
+
+	ReplicaOnly    bool `toml:"replica_only" yaml:"replica_only" json:"replica_only"`             // 哨兵模式：只读命令只发送到从节点
+	ReadOnly       bool `toml:"read_only" yaml:"read_only" json:"read_only"`                      // 集群模式：允许只读命令发送到从节点
+	RouteByLatency bool `toml:"route_by_latency" yaml:"route_by_latency" json:"route_by_latency"` // 哨兵或集群模式：只读命令发送到延迟最低的节点（含主节点）
+	RouteRandomly  bool `toml:"route_randomly" yaml:"route_randomly" json:"route_randomly"`       // 哨兵或集群模式：只读命令随机发送到任一节点（含主节点）
+
+	SentinelUsername string `toml:"sentinel_username" yaml:"sentinel_username" json:"sentinel_username"` // 哨兵节点的 ACL 用户名（可选）
+	SentinelPassword string `toml:"sentinel_password" yaml:"sentinel_password" json:"sentinel_password"` // 哨兵节点的认证密码（可选，与数据节点不同时配置）
+
+	TLS                bool   `toml:"tls" yaml:"tls" json:"tls"`                                                    // 是否启用 TLS，配置了证书文件时自动启用
+	CACertFile         string `toml:"ca_cert_file" yaml:"ca_cert_file" json:"ca_cert_file"`                         // CA 证书文件路径（可选，为空时使用系统证书）
+	CertFile           string `toml:"cert_file" yaml:"cert_file" json:"cert_file"`                                  // 客户端证书文件路径（双向认证时使用）
+	KeyFile            string `toml:"key_file" yaml:"key_file" json:"key_file"`                                     // 客户端私钥文件路径（与 cert_file 配合使用）
+	ServerName         string `toml:"server_name" yaml:"server_name" json:"server_name"`                            // 校验服务端证书使用的域名（可选）
+	InsecureSkipVerify bool   `toml:"insecure_skip_verify" yaml:"insecure_skip_verify" json:"insecure_skip_verify"` // 跳过服务端证书校验（仅限测试环境）
+
+	DialTimeout     int `toml:"dial_timeout" yaml:"dial_timeout" json:"dial_timeout"`                // 建立连接超时（单位：毫秒），0 使用默认 5 秒
+	ReadTimeout     int `toml:"read_timeout" yaml:"read_timeout" json:"read_timeout"`                // 读超时（单位：毫秒），0 使用默认 3 秒，-1 不超时
+	WriteTimeout    int `toml:"write_timeout" yaml:"write_timeout" json:"write_timeout"`             // 写超时（单位：毫秒），0 与读超时相同，-1 不超时
+	PoolTimeout     int `toml:"pool_timeout" yaml:"pool_timeout" json:"pool_timeout"`                // 等待连接池空闲连接的超时（单位：毫秒），0 为读超时 + 1 秒
+	MaxRetries      int `toml:"max_retries" yaml:"max_retries" json:"max_retries"`                   // 命令失败最大重试次数，0 使用默认 3 次，-1 不重试
+	MinRetryBackoff int `toml:"min_retry_backoff" yaml:"min_retry_backoff" json:"min_retry_backoff"` // 重试最小退避时间（单位：毫秒），0 使用默认 8 毫秒，-1 不退避
+	MaxRetryBackoff int `toml:"max_retry_backoff" yaml:"max_retry_backoff" json:"max_retry_backoff"` // 重试最大退避时间（单位：毫秒），0 使用默认 512 毫秒，-1 不退避
+
+	Breaker *breaker.Config `toml:"breaker" yaml:"breaker" json:"breaker"` // 熔断器配置（可选），为空时不启用
+}
+
+// validate 校验配置，未知的模式与早期版本一致按单机模式处理
+func (c *Config) validate() error {
+	switch c.Mode {
+	case SENTINEL:
+		if c.MasterName == "" || len(c.Slaves) == 0 {
+			return errors.New("sentinel mode requires master_name and slaves")
+		}
+	case CLUSTER:
+		if len(c.Slaves) == 0 {
+			return errors.New("cluster mode requires slaves")
+		}
+		if c.DB != 0 {
+			return errors.New("cluster mode only supports db 0")
+		}
+	default:
+		if c.Addr == "" {
+			return errors.New("standalone mode requires addr")
+		}
+	}
+
+	if err := c.validateReplica(); err != nil {
+		return err
+	}
+	if c.Mode != SENTINEL && (c.SentinelUsername != "" || c.SentinelPassword != "") {
+		return errors.New("sentinel_username and sentinel_password are only valid in sentinel mode")
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return errors.New("cert_file and key_file must be set together")
+	}
+
+	if c.DialTimeout < 0 || c.PoolTimeout < 0 {
+		return errors.New("dial_timeout and pool_timeout must not be negative")
+	}
+	if c.ReadTimeout < -1 || c.WriteTimeout < -1 {
+		return errors.New("read_timeout and write_timeout must be >= -1")
+	}
+	if c.MaxRetries < -1 || c.MinRetryBackoff < -1 || c.MaxRetryBackoff < -1 {
+		return errors.New("max_retries, min_retry_backoff and max_retry_backoff must be >= -1")
+	}
+	if c.MinRetryBackoff > 0 && c.MaxRetryBackoff > 0 && c.MinRetryBackoff > c.MaxRetryBackoff {
+		return errors.New("min_retry_backoff must not exceed max_retry_backoff")
+	}
+	if c.PoolSize < 0 || c.MinIdle < 0 {
+		return errors.New("pool_size and min_idle must not be negative")
+	}
+	return nil
+}
+
+// tlsConfig 根据配置创建 TLS 配置，未启用时返回 nil
+func (c *Config) tlsConfig() (*tls.Config, error) {
+	if !c.TLS && c.CACertFile == "" && c.CertFile == "" {
+		return nil, nil
+	}
+	conf := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if c.CACertFile != "" {
+		caCert, err := os.ReadFile(c.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载 CA 文件失败: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("CA 文件中没有有效的证书")
+		}
+		conf.RootCAs = pool
+	}
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载客户端证书失败: %w", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+	return conf, nil
+}
+
+// duration 将毫秒配置转换为 time.Duration，负数原样保留（go-redis 中 -1 表示不超时或关闭）
+func duration(ms int) time.Duration {
+	if ms < 0 {
+		return time.Duration(ms)
+	}
+	return time.Millisecond * time.Duration(ms)
 }
@@ -1,6 +1,8 @@
 package redis
 
 const (
+	// STANDALONE 单机
+	STANDALONE = "standalone"
 	// SENTINEL 哨兵
 	SENTINEL = "sentinel"
 	// CLUSTER 集群
@@ -0,0 +1,500 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/restoflife/ql_common/logger"
+	"go.uber.org/zap"
+)
+
+// DelayQueue 默认参数
+const (
+	DefaultDelayConcurrency = 1                // 并发处理数
+	DefaultDelayVisibility  = 30 * time.Second // 可见性超时，处理超过该时间的任务视为失败并重新投递
+	DefaultDelayMaxAttempts = 5                // 最大执行次数，超过后转入死信队列
+	DefaultDelayBackoff     = time.Second      // 首次重试的等待时间，之后每次翻倍
+	DefaultDelayMaxBackoff  = 10 * time.Minute // 重试等待时间上限
+	DefaultDelayPoll        = time.Second      // 无到期任务时的轮询间隔
+	delayReapBatch          = 100              // 每次回收的超时任务数
+)
+
+// 脚本使用 Redis 服务器时间（毫秒），KEYS 依次为 delayed、processing、leases、jobs、attempts、dead
+
+// delayRetryLua 重试或转入死信：执行次数达到上限时转入死信队列，否则按指数退避重新放回延迟集合
+// ARGV[1] 最大执行次数，ARGV[2] 首次退避毫秒，ARGV[3] 退避上限毫秒
+const delayRetryLua = `
+local function retry(id, now)
+	local attempts = tonumber(redis.call('HGET', KEYS[5], id) or '0')
+	if attempts >= tonumber(ARGV[1]) then
+		redis.call('RPUSH', KEYS[6], id)
+		return 0
+	end
+	local backoff = math.min(tonumber(ARGV[2]) * 2 ^ math.max(attempts - 1, 0), tonumber(ARGV[3]))
+	redis.call('ZADD', KEYS[1], now + backoff, id)
+	return 1
+end
+local t = redis.call('TIME')
+local now = t[1] * 1000 + math.floor(t[2] / 1000)
+`
+
+// delayEnqueueScript 加入任务，任务 ID 已存在（等待、处理中或死信）时不重复加入，返回 0
+// ARGV[1] 任务 ID，ARGV[2] 数据，ARGV[3] 延迟毫秒
+var delayEnqueueScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[4], ARGV[1]) == 1 then
+	return 0
+end
+local t = redis.call('TIME')
+local now = t[1] * 1000 + math.floor(t[2] / 1000)
+redis.call('HSET', KEYS[4], ARGV[1], ARGV[2])
+redis.call('ZADD', KEYS[1], now + tonumber(ARGV[3]), ARGV[1])
+return 1
+`)
+
+// delayDequeueScript 取出一个到期任务放入处理列表并记录租约，返回 {ID, 数据, 第几次执行}
+// ARGV[1] 可见性超时毫秒
+var delayDequeueScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = t[1] * 1000 + math.floor(t[2] / 1000)
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', now, 'LIMIT', 0, 1)
+if #ids == 0 then
+	return false
+end
+local id = ids[1]
+redis.call('ZREM', KEYS[1], id)
+local data = redis.call('HGET', KEYS[4], id)
+if not data then
+	redis.call('HDEL', KEYS[5], id)
+	return false
+end
+redis.call('RPUSH', KEYS[2], id)
+redis.call('ZADD', KEYS[3], now + tonumber(ARGV[1]), id)
+local attempt = redis.call('HINCRBY', KEYS[5], id, 1)
+return {id, data, attempt}
+`)
+
+// delayAckScript 确认任务并删除数据，租约已失效（任务已被回收）时返回 0
+// ARGV[1] 任务 ID
+var delayAckScript = redis.NewScript(`
+if redis.call('LREM', KEYS[2], 1, ARGV[1]) == 0 then
+	return 0
+end
+redis.call('ZREM', KEYS[3], ARGV[1])
+redis.call('HDEL', KEYS[4], ARGV[1])
+redis.call('HDEL', KEYS[5], ARGV[1])
+return 1
+`)
+
+// delayFailScript 任务失败，返回 1 已安排重试，0 已转入死信，-1 租约已失效
+// ARGV[4] 任务 ID
+var delayFailScript = redis.NewScript(delayRetryLua + `
+if redis.call('LREM', KEYS[2], 1, ARGV[4]) == 0 then
+	return -1
+end
+redis.call('ZREM', KEYS[3], ARGV[4])
+return retry(ARGV[4], now)
+`)
+
+// delayReapScript 回收租约已过期的任务（进程崩溃或处理超时），返回回收数量
+// ARGV[4] 每次最多回收的数量
+var delayReapScript = redis.NewScript(delayRetryLua + `
+local ids = redis.call('ZRANGEBYSCORE', KEYS[3], '-inf', now, 'LIMIT', 0, tonumber(ARGV[4]))
+for _, id in ipairs(ids) do
+	redis.call('ZREM', KEYS[3], id)
+	redis.call('LREM', KEYS[2], 1, id)
+	retry(id, now)
+end
+return #ids
+`)
+
+// delayCancelScript 取消尚未开始执行的任务，返回是否取消成功
+// ARGV[1] 任务 ID
+var delayCancelScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('HDEL', KEYS[4], ARGV[1])
+redis.call('HDEL', KEYS[5], ARGV[1])
+return 1
+`)
+
+// delayRequeueScript 将死信任务重新放回延迟集合并清零执行次数，返回是否成功
+// ARGV[1] 任务 ID
+var delayRequeueScript = redis.NewScript(`
+if redis.call('LREM', KEYS[6], 1, ARGV[1]) == 0 then
+	return 0
+end
+local t = redis.call('TIME')
+local now = t[1] * 1000 + math.floor(t[2] / 1000)
+redis.call('HDEL', KEYS[5], ARGV[1])
+redis.call('ZADD', KEYS[1], now, ARGV[1])
+return 1
+`)
+
+// DelayJob 延迟任务
+type DelayJob struct {
+	ID      string // 任务 ID，同一 ID 同时只存在一个任务
+	Payload string // 任务数据
+	Attempt int64  // 第几次执行，从 1 开始
+}
+
+// DelayHandler 任务处理函数，返回 nil 时确认任务，返回错误时按退避重试；ctx 在可见性超时后取消
+type DelayHandler func(ctx context.Context, job *DelayJob) error
+
+// DelayQueueStats 队列长度统计
+type DelayQueueStats struct {
+	Delayed    int64 // 等待执行（含等待重试）
+	Processing int64 // 执行中
+	Dead       int64 // 死信
+}
+
+// delayOptions DelayQueue 的可选参数
+type delayOptions struct {
+	concurrency int
+	visibility  time.Duration
+	maxAttempts int64
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	poll        time.Duration
+	log         *zap.Logger
+}
+
+// DelayOption 是对 DelayQueue 的函数式配置
+type DelayOption func(*delayOptions)
+
+// WithDelayConcurrency 设置并发处理数（默认 1）
+func WithDelayConcurrency(n int) DelayOption {
+	return func(o *delayOptions) {
+		o.concurrency = n
+	}
+}
+
+// WithDelayVisibility 设置可见性超时（默认 30 秒，最小 10 毫秒），处理函数的 ctx 到期取消，租约过期的任务按失败重试
+func WithDelayVisibility(d time.Duration) DelayOption {
+	return func(o *delayOptions) {
+		o.visibility = d
+	}
+}
+
+// WithDelayMaxAttempts 设置最大执行次数（默认 5），达到后转入死信队列
+func WithDelayMaxAttempts(n int64) DelayOption {
+	return func(o *delayOptions) {
+		o.maxAttempts = n
+	}
+}
+
+// WithDelayBackoff 设置重试退避：首次等待 base，之后每次翻倍，最多等待 maxBackoff（默认 1 秒、10 分钟，最小 1 毫秒）
+func WithDelayBackoff(base, maxBackoff time.Duration) DelayOption {
+	return func(o *delayOptions) {
+		o.backoff = base
+		o.maxBackoff = maxBackoff
+	}
+}
+
+// WithDelayPoll 设置无到期任务时的轮询间隔（默认 1 秒），决定任务执行时间的精度
+func WithDelayPoll(d time.Duration) DelayOption {
+	return func(o *delayOptions) {
+		o.poll = d
+	}
+}
+
+// WithDelayLogger 设置日志实例，默认为 logger.Logger()，未初始化时不输出
+func WithDelayLogger(log *zap.Logger) DelayOption {
+	return func(o *delayOptions) {
+		o.log = log
+	}
+}
+
+// DelayQueue 基于有序集合的可靠延迟队列：任务按执行时间存入 ZSET，到期后由脚本原子移入处理列表；
+// 处理成功后确认，失败或超过可见性超时按指数退避重试，执行次数达到上限后转入死信队列。
+// 所有 key 使用 {queue} 哈希标签，集群模式下位于同一槽位
+//
+//	queue := redis.NewDelayQueue("mq", "order:timeout", redis.WithDelayConcurrency(4))
+//	_, err := queue.Enqueue(ctx, orderNo, orderNo, 30*time.Minute)
+//	_, err = queue.Cancel(ctx, orderNo) // 支付成功后取消
+//	go queue.Run(ctx, cancelOrder)
+type DelayQueue struct {
+	handle *Handle
+	queue  string
+	keys   []string
+	opts   delayOptions
+}
+
+// NewDelayQueue 创建延迟队列
+func (h *Handle) NewDelayQueue(queue string, opts ...DelayOption) *DelayQueue {
+	o := delayOptions{
+		concurrency: DefaultDelayConcurrency,
+		visibility:  DefaultDelayVisibility,
+		maxAttempts: DefaultDelayMaxAttempts,
+		backoff:     DefaultDelayBackoff,
+		maxBackoff:  DefaultDelayMaxBackoff,
+		poll:        DefaultDelayPoll,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.concurrency = max(o.concurrency, 1)
+	o.maxAttempts = max(o.maxAttempts, 1)
+	o.poll = max(o.poll, 10*time.Millisecond)
+	// 未设置（<=0）时使用默认值；脚本以毫秒计算，过小的值按最小值处理
+	if o.visibility <= 0 {
+		o.visibility = DefaultDelayVisibility
+	}
+	if o.backoff <= 0 {
+		o.backoff = DefaultDelayBackoff
+	}
+	if o.maxBackoff <= 0 {
+		o.maxBackoff = DefaultDelayMaxBackoff
+	}
+	o.visibility = max(o.visibility, 10*time.Millisecond)
+	o.backoff = max(o.backoff, time.Millisecond)
+	o.maxBackoff = max(o.maxBackoff, o.backoff)
+
+	tag := "{" + queue + "}"
+	keys := []string{tag + ":delayed", tag + ":processing", tag + ":leases", tag + ":jobs", tag + ":attempts", tag + ":dead"}
+	return &DelayQueue{handle: h, queue: queue, keys: keys, opts: o}
+}
+
+// NewDelayQueue 创建指定实例上的延迟队列
+func NewDelayQueue(name, queue string, opts ...DelayOption) *DelayQueue {
+	return Use(name).NewDelayQueue(queue, opts...)
+}
+
+// client 队列命令均在主节点执行
+func (q *DelayQueue) client(ctx context.Context) redis.UniversalClient {
+	return q.handle.pick(WithMaster(ctx))
+}
+
+// Enqueue 加入任务，delay 后执行；任务 ID 已存在（等待、执行中或死信）时不重复加入，返回 false
+func (q *DelayQueue) Enqueue(ctx context.Context, id, payload string, delay time.Duration) (bool, error) {
+	if q.handle.err != nil {
+		return false, q.handle.err
+	}
+	n, err := delayEnqueueScript.Run(ctx, q.client(ctx), q.keys, id, payload, max(delay, 0).Milliseconds()).Int()
+	return n == 1, err
+}
+
+// EnqueueAt 加入任务，在指定时间执行
+func (q *DelayQueue) EnqueueAt(ctx context.Context, id, payload string, at time.Time) (bool, error) {
+	return q.Enqueue(ctx, id, payload, time.Until(at))
+}
+
+// Cancel 取消尚未开始执行的任务，任务不存在或正在执行时返回 false
+func (q *DelayQueue) Cancel(ctx context.Context, id string) (bool, error) {
+	if q.handle.err != nil {
+		return false, q.handle.err
+	}
+	n, err := delayCancelScript.Run(ctx, q.client(ctx), q.keys, id).Int()
+	return n == 1, err
+}
+
+// Stats 返回队列长度统计
+func (q *DelayQueue) Stats(ctx context.Context) (*DelayQueueStats, error) {
+	if q.handle.err != nil {
+		return nil, q.handle.err
+	}
+	var delayed, processing, dead *redis.IntCmd
+	_, err := q.client(ctx).Pipelined(ctx, func(p redis.Pipeliner) error {
+		delayed = p.ZCard(ctx, q.keys[0])
+		processing = p.LLen(ctx, q.keys[1])
+		dead = p.LLen(ctx, q.keys[5])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &DelayQueueStats{Delayed: delayed.Val(), Processing: processing.Val(), Dead: dead.Val()}, nil
+}
+
+// DeadJobs 返回最早进入死信队列的 limit 个任务，Attempt 为已执行次数
+func (q *DelayQueue) DeadJobs(ctx context.Context, limit int64) ([]*DelayJob, error) {
+	if q.handle.err != nil {
+		return nil, q.handle.err
+	}
+	client := q.client(ctx)
+	ids, err := client.LRange(ctx, q.keys[5], 0, limit-1).Result()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	var payloads, attempts *redis.SliceCmd
+	_, err = client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		payloads = p.HMGet(ctx, q.keys[3], ids...)
+		attempts = p.HMGet(ctx, q.keys[4], ids...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*DelayJob, len(ids))
+	for i, id := range ids {
+		job := &DelayJob{ID: id}
+		job.Payload, _ = payloads.Val()[i].(string)
+		if s, ok := attempts.Val()[i].(string); ok {
+			_, _ = fmt.Sscan(s, &job.Attempt)
+		}
+		jobs[i] = job
+	}
+	return jobs, nil
+}
+
+// Requeue 将死信任务重新放入队列立即执行，执行次数清零；任务不在死信队列时返回 false
+func (q *DelayQueue) Requeue(ctx context.Context, id string) (bool, error) {
+	if q.handle.err != nil {
+		return false, q.handle.err
+	}
+	n, err := delayRequeueScript.Run(ctx, q.client(ctx), q.keys, id).Int()
+	return n == 1, err
+}
+
+// Run 开始处理到期任务，阻塞直到 ctx 取消且处理中的任务全部完成
+func (q *DelayQueue) Run(ctx context.Context, handler DelayHandler) error {
+	if q.handle.err != nil {
+		return q.handle.err
+	}
+	if q.opts.log == nil {
+		q.opts.log = logger.Logger()
+	}
+	if q.opts.log == nil {
+		q.opts.log = zap.NewNop()
+	}
+	q.opts.log.Info("延迟队列启动", zap.String("name", q.handle.name), zap.String("queue", q.queue))
+
+	var wg sync.WaitGroup
+	for range q.opts.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx, handler)
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		q.reap(ctx)
+	}()
+	wg.Wait()
+
+	q.opts.log.Info("延迟队列已停止", zap.String("name", q.handle.name), zap.String("queue", q.queue))
+	return nil
+}
+
+// work 循环取出并处理到期任务
+func (q *DelayQueue) work(ctx context.Context, handler DelayHandler) {
+	for ctx.Err() == nil {
+		job, err := q.dequeue(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				q.opts.log.Warn("延迟队列取任务失败", zap.String("name", q.handle.name), zap.String("queue", q.queue), zap.Error(err))
+			}
+			sleepContext(ctx, q.opts.poll)
+			continue
+		}
+		if job == nil {
+			sleepContext(ctx, q.opts.poll)
+			continue
+		}
+		// 处理中的任务不随 ctx 取消，只受可见性超时限制
+		q.process(context.WithoutCancel(ctx), handler, job)
+	}
+}
+
+// dequeue 取出一个到期任务，没有时返回 nil
+func (q *DelayQueue) dequeue(ctx context.Context) (*DelayJob, error) {
+	res, err := delayDequeueScript.Run(ctx, q.client(ctx), q.keys, q.opts.visibility.Milliseconds()).Slice()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	job := &DelayJob{}
+	job.ID, _ = res[0].(string)
+	job.Payload, _ = res[1].(string)
+	job.Attempt, _ = res[2].(int64)
+	return job, nil
+}
+
+// process 执行任务并确认或安排重试
+func (q *DelayQueue) process(ctx context.Context, handler DelayHandler, job *DelayJob) {
+	fields := []zap.Field{
+		zap.String("name", q.handle.name),
+		zap.String("queue", q.queue),
+		zap.String("id", job.ID),
+		zap.Int64("attempt", job.Attempt),
+	}
+	hctx, cancel := context.WithTimeout(ctx, q.opts.visibility)
+	start := time.Now()
+	err := q.call(hctx, handler, job)
+	cancel()
+
+	if err == nil {
+		ok, err := delayAckScript.Run(ctx, q.client(ctx), q.keys, job.ID).Bool()
+		switch {
+		case err != nil:
+			q.opts.log.Warn("延迟任务确认失败", append(fields, zap.Error(err))...)
+		case !ok:
+			q.opts.log.Warn("延迟任务租约已失效，可能被重复执行", append(fields, zap.Duration("cost", time.Since(start)))...)
+		}
+		return
+	}
+
+	fields = append(fields, zap.Duration("cost", time.Since(start)), zap.NamedError("cause", err))
+	res, err := delayFailScript.Run(ctx, q.client(ctx), q.keys,
+		q.opts.maxAttempts, q.opts.backoff.Milliseconds(), q.opts.maxBackoff.Milliseconds(), job.ID).Int()
+	switch {
+	case err != nil:
+		q.opts.log.Error("延迟任务重试安排失败，等待租约过期后回收", append(fields, zap.Error(err))...)
+	case res == 0:
+		q.opts.log.Error("延迟任务转入死信", fields...)
+	case res < 0:
+		q.opts.log.Warn("延迟任务处理失败，租约已失效", fields...)
+	default:
+		q.opts.log.Warn("延迟任务处理失败，稍后重试", fields...)
+	}
+}
+
+// call 调用处理函数，panic 视为处理失败
+func (q *DelayQueue) call(ctx context.Context, handler DelayHandler, job *DelayJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+			q.opts.log.Error("延迟任务处理panic", zap.String("name", q.handle.name), zap.String("queue", q.queue), zap.String("id", job.ID),
+				zap.Any("panic", r), zap.ByteString("stack", debug.Stack()))
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// reap 定期回收租约过期的任务
+func (q *DelayQueue) reap(ctx context.Context) {
+	ticker := time.NewTicker(max(q.opts.visibility/2, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		n, err := q.reapExpired(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				q.opts.log.Warn("延迟队列回收超时任务失败", zap.String("name", q.handle.name), zap.String("queue", q.queue), zap.Error(err))
+			}
+			continue
+		}
+		if n > 0 {
+			q.opts.log.Warn("延迟队列回收超时任务", zap.String("name", q.handle.name), zap.String("queue", q.queue), zap.Int("count", n))
+		}
+	}
+}
+
+// reapExpired 回收一批租约过期的任务，返回回收数量
+func (q *DelayQueue) reapExpired(ctx context.Context) (int, error) {
+	return delayReapScript.Run(ctx, q.client(ctx), q.keys,
+		q.opts.maxAttempts, q.opts.backoff.Milliseconds(), q.opts.maxBackoff.Milliseconds(), delayReapBatch).Int()
+}
@@ -0,0 +1,115 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDelayQueueOptions(t *testing.T) {
+	q := NewDelayQueue("default", "q", WithDelayVisibility(0), WithDelayBackoff(time.Microsecond, -1))
+	if q.opts.visibility != DefaultDelayVisibility || q.opts.backoff != time.Millisecond || q.opts.maxBackoff != DefaultDelayMaxBackoff {
+		t.Fatalf("unexpected options: %+v", q.opts)
+	}
+}
+
+func TestDelayQueueDedupe(t *testing.T) {
+	newTestRedis(t)
+	ctx := context.Background()
+	q := NewDelayQueue("default", "orders")
+
+	if ok, err := q.Enqueue(ctx, "1", "a", time.Minute); err != nil || !ok {
+		t.Fatalf("Enqueue = %v, %v", ok, err)
+	}
+	// 同一 ID 已存在时不重复加入
+	if ok, err := q.Enqueue(ctx, "1", "b", 0); err != nil || ok {
+		t.Fatalf("duplicate Enqueue = %v, %v", ok, err)
+	}
+	stats, err := q.Stats(ctx)
+	if err != nil || stats.Delayed != 1 {
+		t.Fatalf("Stats = %+v, %v", stats, err)
+	}
+	if ok, err := q.Cancel(ctx, "1"); err != nil || !ok {
+		t.Fatalf("Cancel = %v, %v", ok, err)
+	}
+	if ok, err := q.Enqueue(ctx, "1", "b", 0); err != nil || !ok {
+		t.Fatalf("Enqueue after cancel = %v, %v", ok, err)
+	}
+}
+
+func TestDelayQueueRetryToDeadLetter(t *testing.T) {
+	newTestRedis(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q := NewDelayQueue("default", "orders", WithDelayMaxAttempts(3),
+		WithDelayBackoff(time.Millisecond, 5*time.Millisecond), WithDelayPoll(10*time.Millisecond))
+
+	if _, err := q.Enqueue(ctx, "1", "a", 0); err != nil {
+		t.Fatal(err)
+	}
+	attempts := make(chan int64, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- q.Run(ctx, func(_ context.Context, job *DelayJob) error {
+			attempts <- job.Attempt
+			return errors.New("fail")
+		})
+	}()
+
+	// 执行 3 次后转入死信
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if stats, _ := q.Stats(ctx); stats != nil && stats.Dead == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+	close(attempts)
+	var got []int64
+	for a := range attempts {
+		got = append(got, a)
+	}
+	if len(got) != 3 || got[2] != 3 {
+		t.Fatalf("attempts = %v", got)
+	}
+	jobs, err := q.DeadJobs(context.Background(), 10)
+	if err != nil || len(jobs) != 1 || jobs[0].ID != "1" {
+		t.Fatalf("DeadJobs = %+v, %v", jobs, err)
+	}
+}
+
+func TestDelayQueueReap(t *testing.T) {
+	m := newTestRedis(t)
+	ctx := context.Background()
+	now := time.Now()
+	m.SetTime(now)
+	q := NewDelayQueue("default", "orders", WithDelayVisibility(time.Second), WithDelayBackoff(time.Second, time.Second))
+
+	if _, err := q.Enqueue(ctx, "1", "a", 0); err != nil {
+		t.Fatal(err)
+	}
+	// 取出任务后不确认，模拟进程崩溃
+	job, err := q.dequeue(ctx)
+	if err != nil || job == nil || job.Attempt != 1 {
+		t.Fatalf("dequeue = %+v, %v", job, err)
+	}
+	if n, err := q.reapExpired(ctx); err != nil || n != 0 {
+		t.Fatalf("reap before lease expiry = %d, %v", n, err)
+	}
+
+	// 租约过期后回收并按退避重新放回延迟集合
+	m.SetTime(now.Add(2 * time.Second))
+	if n, err := q.reapExpired(ctx); err != nil || n != 1 {
+		t.Fatalf("reap = %d, %v", n, err)
+	}
+	if job, err = q.dequeue(ctx); err != nil || job != nil {
+		t.Fatalf("dequeue during backoff = %+v, %v", job, err)
+	}
+	m.SetTime(now.Add(4 * time.Second))
+	if job, err = q.dequeue(ctx); err != nil || job == nil || job.Attempt != 2 {
+		t.Fatalf("dequeue after backoff = %+v, %v", job, err)
+	}
+}
@@ -0,0 +1,756 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Handle 绑定单个 Redis 实例的客户端句柄，所有方法的第一个参数为 context，
+// 请求的取消与截止时间会传递到 Redis 命令
+//
+//	cache := redis.Use("cache")
+//	v, err := cache.Get(ctx, "key")
+type Handle struct {
+	name   string
+	client redis.UniversalClient
+	master redis.UniversalClient // 开启副本读时的仅主节点客户端
+	err    error                 // 实例不存在时的错误，每个方法都会返回该错误
+}
+
+// Use 获取指定实例的句柄（需在 MustBootUpRedis 之后调用），实例不存在时所有方法返回错误
+func Use(name string) *Handle {
+	client, err := GetRedis(name)
+	return &Handle{name: name, client: client, master: masterMgr[name], err: err}
+}
+
+// pick 选择执行命令的客户端，context 经 WithMaster 标记时使用主节点客户端
+func (h *Handle) pick(ctx context.Context) redis.UniversalClient {
+	if h.master != nil && isMaster(ctx) {
+		return h.master
+	}
+	return h.client
+}
+
+// primary 返回主节点客户端，未开启副本读时与 client 相同
+// 事务、WATCH 与脚本必须在同一节点上执行，不能交给 FailoverClusterClient 按命令路由
+func (h *Handle) primary() redis.UniversalClient {
+	if h.master != nil {
+		return h.master
+	}
+	return h.client
+}
+
+// Name 返回实例名称
+func (h *Handle) Name() string {
+	return h.name
+}
+
+// Client 返回底层 go-redis 客户端，用于调用句柄未封装的命令
+func (h *Handle) Client() (redis.UniversalClient, error) {
+	return h.client, h.err
+}
+
+// ==================== String 操作 ====================
+
+// Set 设置字符串值
+func (h *Handle) Set(ctx context.Context, key string, value any, expiration time.Duration) error {
+	if h.err != nil {
+		return h.err
+	}
+	return h.pick(ctx).Set(ctx, key, value, expiration).Err()
+}
+
+// SetNX 仅当键不存在时设置值
+func (h *Handle) SetNX(ctx context.Context, key string, value any, expiration time.Duration) (bool, error) {
+	if h.err != nil {
+		return false, h.err
+	}
+	return h.pick(ctx).SetNX(ctx, key, value, expiration).Result()
+}
+
+// SetXX 仅当键存在时设置值
+func (h *Handle) SetXX(ctx context.Context, key string, value any, expiration time.Duration) (bool, error) {
+	if h.err != nil {
+		return false, h.err
+	}
+	return h.pick(ctx).SetXX(ctx, key, value, expiration).Result()
+}
+
+// Get 获取字符串值
+func (h *Handle) Get(ctx context.Context, key string) (string, error) {
+	if h.err != nil {
+		return "", h.err
+	}
+	return h.pick(ctx).Get(ctx, key).Result()
+}
+
+// GetDel 获取并删除字符串值
+func (h *Handle) GetDel(ctx context.Context, key string) (string, error) {
+	if h.err != nil {
+		return "", h.err
+	}
+	return h.pick(ctx).GetDel(ctx, key).Result()
+}
+
+// GetEx 获取值并设置过期时间
+func (h *Handle) GetEx(ctx context.Context, key string, expiration time.Duration) (string, error) {
+	if h.err != nil {
+		return "", h.err
+	}
+	return h.pick(ctx).GetEx(ctx, key, expiration).Result()
+}
+
+// Incr 递增整数
+func (h *Handle) Incr(ctx context.Context, key string) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).Incr(ctx, key).Result()
+}
+
+// IncrBy 递增指定值
+func (h *Handle) IncrBy(ctx context.Context, key string, value int64) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).IncrBy(ctx, key, value).Result()
+}
+
+// IncrByFloat 递增浮点数
+func (h *Handle) IncrByFloat(ctx context.Context, key string, value float64) (float64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).IncrByFloat(ctx, key, value).Result()
+}
+
+// Decr 递减整数
+func (h *Handle) Decr(ctx context.Context, key string) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).Decr(ctx, key).Result()
+}
+
+// DecrBy 递减指定值
+func (h *Handle) DecrBy(ctx context.Context, key string, value int64) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).DecrBy(ctx, key, value).Result()
+}
+
+// Append 追加字符串
+func (h *Handle) Append(ctx context.Context, key, value string) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).Append(ctx, key, value).Result()
+}
+
+// MGet 批量获取值
+func (h *Handle) MGet(ctx context.Context, keys ...string) ([]any, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	return h.pick(ctx).MGet(ctx, keys...).Result()
+}
+
+// MSet 批量设置值
+func (h *Handle) MSet(ctx context.Context, values ...any) error {
+	if h.err != nil {
+		return h.err
+	}
+	return h.pick(ctx).MSet(ctx, values...).Err()
+}
+
+// GetRange 获取子字符串
+func (h *Handle) GetRange(ctx context.Context, key string, start, end int64) (string, error) {
+	if h.err != nil {
+		return "", h.err
+	}
+	return h.pick(ctx).GetRange(ctx, key, start, end).Result()
+}
+
+// SetRange 替换子字符串
+func (h *Handle) SetRange(ctx context.Context, key string, offset int64, value string) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).SetRange(ctx, key, offset, value).Result()
+}
+
+// StrLen 获取字符串长度
+func (h *Handle) StrLen(ctx context.Context, key string) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).StrLen(ctx, key).Result()
+}
+
+// ==================== Hash 操作 ====================
+
+// HSet 设置哈希字段值
+func (h *Handle) HSet(ctx context.Context, key, field string, value any) error {
+	if h.err != nil {
+		return h.err
+	}
+	return h.pick(ctx).HSet(ctx, key, field, value).Err()
+}
+
+// HSetNX 仅当字段不存在时设置
+func (h *Handle) HSetNX(ctx context.Context, key, field string, value any) (bool, error) {
+	if h.err != nil {
+		return false, h.err
+	}
+	return h.pick(ctx).HSetNX(ctx, key, field, value).Result()
+}
+
+// HGet 获取哈希字段值
+func (h *Handle) HGet(ctx context.Context, key, field string) (string, error) {
+	if h.err != nil {
+		return "", h.err
+	}
+	return h.pick(ctx).HGet(ctx, key, field).Result()
+}
+
+// HGetAll 获取所有哈希字段和值
+func (h *Handle) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	return h.pick(ctx).HGetAll(ctx, key).Result()
+}
+
+// HMSet 批量设置哈希字段值
+func (h *Handle) HMSet(ctx context.Context, key string, values any) error {
+	if h.err != nil {
+		return h.err
+	}
+	return h.pick(ctx).HMSet(ctx, key, values).Err()
+}
+
+// HMGet 批量获取哈希字段值
+func (h *Handle) HMGet(ctx context.Context, key string, fields ...string) ([]any, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	return h.pick(ctx).HMGet(ctx, key, fields...).Result()
+}
+
+// HDel 删除哈希字段
+func (h *Handle) HDel(ctx context.Context, key string, fields ...string) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).HDel(ctx, key, fields...).Result()
+}
+
+// HExists 检查哈希字段是否存在
+func (h *Handle) HExists(ctx context.Context, key, field string) (bool, error) {
+	if h.err != nil {
+		return false, h.err
+	}
+	return h.pick(ctx).HExists(ctx, key, field).Result()
+}
+
+// HIncrBy 哈希字段值递增
+func (h *Handle) HIncrBy(ctx context.Context, key, field string, incr int64) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).HIncrBy(ctx, key, field, incr).Result()
+}
+
+// HIncrByFloat 哈希字段值浮点数递增
+func (h *Handle) HIncrByFloat(ctx context.Context, key, field string, incr float64) (float64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).HIncrByFloat(ctx, key, field, incr).Result()
+}
+
+// HKeys 获取所有哈希字段
+func (h *Handle) HKeys(ctx context.Context, key string) ([]string, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	return h.pick(ctx).HKeys(ctx, key).Result()
+}
+
+// HLen 获取哈希字段数量
+func (h *Handle) HLen(ctx context.Context, key string) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).HLen(ctx, key).Result()
+}
+
+// HVals 获取所有哈希值
+func (h *Handle) HVals(ctx context.Context, key string) ([]string, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	return h.pick(ctx).HVals(ctx, key).Result()
+}
+
+// ==================== List 操作 ====================
+
+// LPush 将元素推入列表左侧
+func (h *Handle) LPush(ctx context.Context, key string, values ...any) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).LPush(ctx, key, values...).Result()
+}
+
+// RPush 将元素推入列表右侧
+func (h *Handle) RPush(ctx context.Context, key string, values ...any) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).RPush(ctx, key, values...).Result()
+}
+
+// LPop 弹出列表左侧元素
+func (h *Handle) LPop(ctx context.Context, key string) (string, error) {
+	if h.err != nil {
+		return "", h.err
+	}
+	return h.pick(ctx).LPop(ctx, key).Result()
+}
+
+// RPop 弹出列表右侧元素
+func (h *Handle) RPop(ctx context.Context, key string) (string, error) {
+	if h.err != nil {
+		return "", h.err
+	}
+	return h.pick(ctx).RPop(ctx, key).Result()
+}
+
+// LRange 获取列表范围内元素
+func (h *Handle) LRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	return h.pick(ctx).LRange(ctx, key, start, stop).Result()
+}
+
+// LLen 获取列表长度
+func (h *Handle) LLen(ctx context.Context, key string) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).LLen(ctx, key).Result()
+}
+
+// LIndex 获取列表指定索引的元素
+func (h *Handle) LIndex(ctx context.Context, key string, index int64) (string, error) {
+	if h.err != nil {
+		return "", h.err
+	}
+	return h.pick(ctx).LIndex(ctx, key, index).Result()
+}
+
+// LInsert 在列表指定位置插入元素
+func (h *Handle) LInsert(ctx context.Context, key, op string, pivot, value any) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).LInsert(ctx, key, op, pivot, value).Result()
+}
+
+// LRem 从列表中移除元素
+func (h *Handle) LRem(ctx context.Context, key string, count int64, value any) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).LRem(ctx, key, count, value).Result()
+}
+
+// LTrim 裁剪列表
+func (h *Handle) LTrim(ctx context.Context, key string, start, stop int64) error {
+	if h.err != nil {
+		return h.err
+	}
+	return h.pick(ctx).LTrim(ctx, key, start, stop).Err()
+}
+
+// LSet 设置列表指定索引的值
+func (h *Handle) LSet(ctx context.Context, key string, index int64, value any) error {
+	if h.err != nil {
+		return h.err
+	}
+	return h.pick(ctx).LSet(ctx, key, index, value).Err()
+}
+
+// ==================== Set 操作 ====================
+
+// SAdd 向集合添加成员
+func (h *Handle) SAdd(ctx context.Context, key string, members ...any) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).SAdd(ctx, key, members...).Result()
+}
+
+// SRem 从集合移除成员
+func (h *Handle) SRem(ctx context.Context, key string, members ...any) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).SRem(ctx, key, members...).Result()
+}
+
+// SMembers 获取集合所有成员
+func (h *Handle) SMembers(ctx context.Context, key string) ([]string, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	return h.pick(ctx).SMembers(ctx, key).Result()
+}
+
+// SIsMember 检查成员是否在集合中
+func (h *Handle) SIsMember(ctx context.Context, key string, member any) (bool, error) {
+	if h.err != nil {
+		return false, h.err
+	}
+	return h.pick(ctx).SIsMember(ctx, key, member).Result()
+}
+
+// SCard 获取集合基数
+func (h *Handle) SCard(ctx context.Context, key string) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).SCard(ctx, key).Result()
+}
+
+// SPop 随机弹出集合成员
+func (h *Handle) SPop(ctx context.Context, key string) (string, error) {
+	if h.err != nil {
+		return "", h.err
+	}
+	return h.pick(ctx).SPop(ctx, key).Result()
+}
+
+// SUnion 返回多个集合的并集
+func (h *Handle) SUnion(ctx context.Context, keys ...string) ([]string, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	return h.pick(ctx).SUnion(ctx, keys...).Result()
+}
+
+// SInter 返回多个集合的交集
+func (h *Handle) SInter(ctx context.Context, keys ...string) ([]string, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	return h.pick(ctx).SInter(ctx, keys...).Result()
+}
+
+// SDiff 返回多个集合的差集
+func (h *Handle) SDiff(ctx context.Context, keys ...string) ([]string, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	return h.pick(ctx).SDiff(ctx, keys...).Result()
+}
+
+// ==================== Sorted Set 操作 ====================
+
+// ZAdd 向有序集合添加成员
+func (h *Handle) ZAdd(ctx context.Context, key string, members ...redis.Z) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).ZAdd(ctx, key, members...).Result()
+}
+
+// ZRem 从有序集合移除成员
+func (h *Handle) ZRem(ctx context.Context, key string, members ...any) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).ZRem(ctx, key, members...).Result()
+}
+
+// ZRange 获取有序集合指定范围的成员
+func (h *Handle) ZRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	return h.pick(ctx).ZRange(ctx, key, start, stop).Result()
+}
+
+// ZRangeWithScores 获取有序集合指定范围的成员及分数
+func (h *Handle) ZRangeWithScores(ctx context.Context, key string, start, stop int64) ([]redis.Z, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	return h.pick(ctx).ZRangeWithScores(ctx, key, start, stop).Result()
+}
+
+// ZRank 获取成员在有序集合中的排名
+func (h *Handle) ZRank(ctx context.Context, key, member string) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).ZRank(ctx, key, member).Result()
+}
+
+// ZScore 获取成员的分数
+func (h *Handle) ZScore(ctx context.Context, key, member string) (float64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).ZScore(ctx, key, member).Result()
+}
+
+// ZIncrBy 递增成员的分数
+func (h *Handle) ZIncrBy(ctx context.Context, key, member string, increment float64) (float64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).ZIncrBy(ctx, key, increment, member).Result()
+}
+
+// ZCard 获取有序集合基数
+func (h *Handle) ZCard(ctx context.Context, key string) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).ZCard(ctx, key).Result()
+}
+
+// ZCount 获取指定分数范围内的成员数量
+func (h *Handle) ZCount(ctx context.Context, key, min, max string) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).ZCount(ctx, key, min, max).Result()
+}
+
+// ZRemRangeByRank 按排名范围移除成员
+func (h *Handle) ZRemRangeByRank(ctx context.Context, key string, start, stop int64) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).ZRemRangeByRank(ctx, key, start, stop).Result()
+}
+
+// ZRemRangeByScore 按分数范围移除成员
+func (h *Handle) ZRemRangeByScore(ctx context.Context, key, min, max string) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).ZRemRangeByScore(ctx, key, min, max).Result()
+}
+
+// ==================== Key 操作 ====================
+
+// Exists 检查键是否存在
+func (h *Handle) Exists(ctx context.Context, keys ...string) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).Exists(ctx, keys...).Result()
+}
+
+// Del 删除键
+func (h *Handle) Del(ctx context.Context, keys ...string) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).Del(ctx, keys...).Result()
+}
+
+// Expire 设置键的过期时间
+func (h *Handle) Expire(ctx context.Context, key string, expiration time.Duration) (bool, error) {
+	if h.err != nil {
+		return false, h.err
+	}
+	return h.pick(ctx).Expire(ctx, key, expiration).Result()
+}
+
+// ExpireAt 设置键的过期时间点
+func (h *Handle) ExpireAt(ctx context.Context, key string, tm time.Time) (bool, error) {
+	if h.err != nil {
+		return false, h.err
+	}
+	return h.pick(ctx).ExpireAt(ctx, key, tm).Result()
+}
+
+// TTL 获取键的剩余过期时间
+func (h *Handle) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).TTL(ctx, key).Result()
+}
+
+// Persist 移除键的过期时间
+func (h *Handle) Persist(ctx context.Context, key string) (bool, error) {
+	if h.err != nil {
+		return false, h.err
+	}
+	return h.pick(ctx).Persist(ctx, key).Result()
+}
+
+// Rename 重命名键
+func (h *Handle) Rename(ctx context.Context, key, newkey string) error {
+	if h.err != nil {
+		return h.err
+	}
+	return h.pick(ctx).Rename(ctx, key, newkey).Err()
+}
+
+// RenameNX 仅当新键不存在时重命名
+func (h *Handle) RenameNX(ctx context.Context, key, newkey string) (bool, error) {
+	if h.err != nil {
+		return false, h.err
+	}
+	return h.pick(ctx).RenameNX(ctx, key, newkey).Result()
+}
+
+// Type 获取键的类型
+func (h *Handle) Type(ctx context.Context, key string) (string, error) {
+	if h.err != nil {
+		return "", h.err
+	}
+	return h.pick(ctx).Type(ctx, key).Result()
+}
+
+// Keys 查找匹配的键
+func (h *Handle) Keys(ctx context.Context, pattern string) ([]string, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	return h.pick(ctx).Keys(ctx, pattern).Result()
+}
+
+// Scan 迭代键
+func (h *Handle) Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	if h.err != nil {
+		return nil, 0, h.err
+	}
+	return h.pick(ctx).Scan(ctx, cursor, match, count).Result()
+}
+
+// ==================== 批量操作 ====================
+
+// Pipeline 执行批量命令
+func (h *Handle) Pipeline(ctx context.Context, fn func(redis.Pipeliner) error) error {
+	if h.err != nil {
+		return h.err
+	}
+	_, err := h.pick(ctx).Pipelined(ctx, fn)
+	return err
+}
+
+// TxPipeline 执行事务批量命令，始终发送到主节点
+func (h *Handle) TxPipeline(ctx context.Context, fn func(redis.Pipeliner) error) error {
+	if h.err != nil {
+		return h.err
+	}
+	_, err := h.primary().TxPipelined(ctx, fn)
+	return err
+}
+
+// Watch 监视 keys 并在 fn 中执行乐观锁事务，始终发送到主节点
+func (h *Handle) Watch(ctx context.Context, fn func(*redis.Tx) error, keys ...string) error {
+	if h.err != nil {
+		return h.err
+	}
+	return h.primary().Watch(ctx, fn, keys...)
+}
+
+// ==================== 脚本操作 ====================
+// 脚本可能包含写操作，始终发送到主节点
+
+// Eval 执行 Lua 脚本
+func (h *Handle) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	return h.primary().Eval(ctx, script, keys, args...).Result()
+}
+
+// EvalSha 执行 Lua 脚本（使用 SHA）
+func (h *Handle) EvalSha(ctx context.Context, sha1 string, keys []string, args ...any) (any, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	return h.primary().EvalSha(ctx, sha1, keys, args...).Result()
+}
+
+// ScriptLoad 加载 Lua 脚本
+func (h *Handle) ScriptLoad(ctx context.Context, script string) (string, error) {
+	if h.err != nil {
+		return "", h.err
+	}
+	return h.primary().ScriptLoad(ctx, script).Result()
+}
+
+// ScriptExists 检查脚本是否已加载
+func (h *Handle) ScriptExists(ctx context.Context, hashes ...string) ([]bool, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	return h.primary().ScriptExists(ctx, hashes...).Result()
+}
+
+// ==================== HyperLogLog 操作 ====================
+
+// PFAdd 添加元素到 HyperLogLog
+func (h *Handle) PFAdd(ctx context.Context, key string, elements ...any) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).PFAdd(ctx, key, elements...).Result()
+}
+
+// PFCount 获取 HyperLogLog 的基数估计
+func (h *Handle) PFCount(ctx context.Context, keys ...string) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).PFCount(ctx, keys...).Result()
+}
+
+// PFMerge 合并多个 HyperLogLog
+func (h *Handle) PFMerge(ctx context.Context, dest string, keys ...string) error {
+	if h.err != nil {
+		return h.err
+	}
+	return h.pick(ctx).PFMerge(ctx, dest, keys...).Err()
+}
+
+// ==================== Bitmap 操作 ====================
+
+// SetBit 设置位
+func (h *Handle) SetBit(ctx context.Context, key string, offset int64, value int) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).SetBit(ctx, key, offset, value).Result()
+}
+
+// GetBit 获取位
+func (h *Handle) GetBit(ctx context.Context, key string, offset int64) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).GetBit(ctx, key, offset).Result()
+}
+
+// BitCount 获取位图中设置为 1 的数量
+func (h *Handle) BitCount(ctx context.Context, key string) (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	return h.pick(ctx).BitCount(ctx, key, nil).Result()
+}
@@ -0,0 +1,40 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHandle(t *testing.T) {
+	newTestRedis(t)
+	ctx := context.Background()
+
+	h := Use("default")
+	if err := h.Set(ctx, "k", "v", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := h.Get(ctx, "k"); err != nil || v != "v" {
+		t.Fatalf("Get = %q, %v", v, err)
+	}
+
+	// 已取消的 context 传递到命令
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := h.Get(canceled, "k"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled Get: %v", err)
+	}
+
+	// 实例不存在时每个方法都返回错误，不会 panic
+	missing := Use("missing")
+	if _, err := missing.Client(); err == nil {
+		t.Fatal("expected missing instance error")
+	}
+	if err := missing.Set(ctx, "k", "v", 0); err == nil {
+		t.Fatal("expected Set error")
+	}
+	if _, err := missing.Eval(ctx, "return 1", nil); err == nil {
+		t.Fatal("expected Eval error")
+	}
+}
@@ -0,0 +1,247 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/restoflife/ql_common/logger"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrNotFound 数据不存在，loader 返回该错误时会缓存“不存在”，GetOrLoad 同样返回该错误
+var ErrNotFound = errors.New("redis: not found")
+
+// GetOrLoad 默认参数
+const (
+	DefaultLoadJitter       = 0.1                   // 过期时间随机增加的最大比例
+	DefaultLoadRefreshRatio = 0.1                   // 剩余时间低于该比例时后台提前刷新
+	DefaultLoadLockTTL      = 5 * time.Second       // 跨进程加载锁的过期时间
+	loadPollInterval        = 50 * time.Millisecond // 等待其他进程加载时的轮询间隔
+)
+
+// 进程内合并同一 key 的并发加载
+var loadGroup singleflight.Group
+
+// 正在后台提前刷新的 key
+var refreshing sync.Map // map[string]struct{}
+
+// loadOptions GetOrLoad 的可选参数
+type loadOptions struct {
+	notFoundTTL  time.Duration
+	jitter       float64
+	refreshRatio float64
+	lockWait     time.Duration
+}
+
+// LoadOption 是对 GetOrLoad 的函数式配置
+type LoadOption func(*loadOptions)
+
+// WithNotFoundTTL 设置“不存在”的缓存时间，默认为 ttl 的 1/10（至少 1 秒），小于 0 时不缓存
+func WithNotFoundTTL(ttl time.Duration) LoadOption {
+	return func(o *loadOptions) {
+		o.notFoundTTL = ttl
+	}
+}
+
+// WithJitter 设置过期时间随机增加的最大比例（默认 0.1），避免大量 key 同时过期
+func WithJitter(ratio float64) LoadOption {
+	return func(o *loadOptions) {
+		o.jitter = ratio
+	}
+}
+
+// WithEarlyRefresh 剩余时间低于 ttl*ratio 时在后台提前刷新（默认 0.1），为 0 时关闭
+func WithEarlyRefresh(ratio float64) LoadOption {
+	return func(o *loadOptions) {
+		o.refreshRatio = ratio
+	}
+}
+
+// WithLoadLock 开启跨进程加载锁：未拿到锁的进程最多等待 wait 读取其他进程加载的结果，超时后自行加载
+func WithLoadLock(wait time.Duration) LoadOption {
+	return func(o *loadOptions) {
+		o.lockWait = wait
+	}
+}
+
+// GetOrLoad 读取缓存（使用默认编码，见 SetDefaultCodec），未命中时调用 loader 加载并写入缓存
+// 进程内同一 key 的并发加载只执行一次；loader 返回 ErrNotFound 时以较短的时间缓存“不存在”
+// Redis 不可用时直接调用 loader；ttl 必须大于 0
+//
+//	product, err := redis.GetOrLoad(ctx, "cache", "product:1001", 10*time.Minute,
+//		func(ctx context.Context) (*Product, error) { return loadProduct(ctx, 1001) })
+func GetOrLoad[T any](ctx context.Context, name, key string, ttl time.Duration, loader func(context.Context) (T, error), opts ...LoadOption) (T, error) {
+	if ttl <= 0 {
+		var zero T
+		return zero, fmt.Errorf("redis: GetOrLoad ttl must be positive: %s", ttl)
+	}
+	o := loadOptions{jitter: DefaultLoadJitter, refreshRatio: DefaultLoadRefreshRatio}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.notFoundTTL == 0 {
+		o.notFoundTTL = max(ttl/10, time.Second)
+	}
+
+	l := &cacheLoader[T]{handle: Use(name), key: key, ttl: ttl, loader: loader, opts: o, codec: newCodecOptions()}
+	return l.get(ctx)
+}
+
+// cacheLoader 单次 GetOrLoad 调用
+type cacheLoader[T any] struct {
+	handle *Handle
+	key    string
+	ttl    time.Duration
+	loader func(context.Context) (T, error)
+	opts   loadOptions
+	codec  codecOptions
+}
+
+func (l *cacheLoader[T]) get(ctx context.Context) (T, error) {
+	var zero T
+	if l.handle.err != nil {
+		return zero, l.handle.err
+	}
+
+	data, remaining, err := l.read(ctx)
+	switch {
+	case err == nil:
+		if l.opts.refreshRatio > 0 && remaining > 0 && remaining < time.Duration(float64(l.ttl)*l.opts.refreshRatio) {
+			l.refresh()
+		}
+		v, err := l.decode(data)
+		if err == nil || errors.Is(err, ErrNotFound) {
+			return v, err
+		}
+		// 无法解析（如切换了编码）时按未命中处理
+		logger.Warn("Redis缓存解析失败", zap.String("name", l.handle.name), zap.String("key", l.key), zap.Error(err))
+	case !errors.Is(err, redis.Nil):
+		// Redis 不可用时降级为直接加载
+		logger.Warn("Redis缓存读取失败", zap.String("name", l.handle.name), zap.String("key", l.key), zap.Error(err))
+	}
+
+	ch := loadGroup.DoChan(l.groupKey(), func() (any, error) {
+		// 加载与调用方的取消解耦，避免一个请求取消导致所有等待者失败
+		return l.load(context.WithoutCancel(ctx))
+	})
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return zero, res.Err
+		}
+		if v, ok := res.Val.(T); ok {
+			return v, nil
+		}
+		// 同一 key 被以不同类型调用时，不共享结果
+		return l.loader(ctx)
+	}
+}
+
+// read 读取缓存及剩余时间
+func (l *cacheLoader[T]) read(ctx context.Context) (string, time.Duration, error) {
+	var get *redis.StringCmd
+	var pttl *redis.DurationCmd
+	_, err := l.handle.pick(ctx).Pipelined(ctx, func(p redis.Pipeliner) error {
+		get = p.Get(ctx, l.key)
+		pttl = p.PTTL(ctx, l.key)
+		return nil
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	return get.Val(), pttl.Val(), nil
+}
+
+// decode 解析缓存，空字符串表示“不存在”
+func (l *cacheLoader[T]) decode(data string) (T, error) {
+	var v T
+	if data == "" {
+		return v, ErrNotFound
+	}
+	if err := l.codec.decode([]byte(data), &v); err != nil {
+		return v, err
+	}
+	return v, nil
+}
+
+// load 调用 loader 并写入缓存；开启加载锁时先尝试获取锁，未获取到则等待其他进程的结果
+func (l *cacheLoader[T]) load(ctx context.Context) (any, error) {
+	if l.opts.lockWait > 0 {
+		lock := l.handle.NewLock(l.key+":load", WithLockTTL(DefaultLoadLockTTL), WithoutWatchdog())
+		if err := lock.TryLock(ctx); err == nil {
+			defer func() { _ = lock.Unlock(ctx) }()
+		} else if errors.Is(err, ErrLockNotObtained) {
+			if data, ok := l.wait(ctx); ok {
+				return l.decode(data)
+			}
+		}
+	}
+
+	v, err := l.loader(ctx)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) && l.opts.notFoundTTL > 0 {
+			l.write(ctx, "", l.opts.notFoundTTL)
+		}
+		return v, err
+	}
+	data, err := l.codec.encode(v)
+	if err != nil {
+		return v, err
+	}
+	l.write(ctx, string(data), l.jittered(l.ttl))
+	return v, nil
+}
+
+// wait 等待其他进程写入缓存
+func (l *cacheLoader[T]) wait(ctx context.Context) (string, bool) {
+	deadline := time.Now().Add(l.opts.lockWait)
+	for time.Now().Before(deadline) {
+		time.Sleep(loadPollInterval)
+		data, err := l.handle.pick(WithMaster(ctx)).Get(ctx, l.key).Result()
+		if err == nil {
+			return data, true
+		}
+	}
+	return "", false
+}
+
+// refresh 后台提前刷新（同一 key 同时只有一个刷新，已在刷新时不再启动协程）
+func (l *cacheLoader[T]) refresh() {
+	key := l.groupKey()
+	if _, loaded := refreshing.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+	go func() {
+		defer refreshing.Delete(key)
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultLoadLockTTL)
+		defer cancel()
+		_, _ = l.load(ctx)
+	}()
+}
+
+// write 写入缓存，失败只记录日志
+func (l *cacheLoader[T]) write(ctx context.Context, data string, ttl time.Duration) {
+	if err := l.handle.pick(ctx).Set(ctx, l.key, data, ttl).Err(); err != nil {
+		logger.Warn("Redis缓存写入失败", zap.String("name", l.handle.name), zap.String("key", l.key), zap.Error(err))
+	}
+}
+
+// jittered 为过期时间增加随机量
+func (l *cacheLoader[T]) jittered(ttl time.Duration) time.Duration {
+	if l.opts.jitter <= 0 {
+		return ttl
+	}
+	return ttl + time.Duration(rand.Float64()*l.opts.jitter*float64(ttl))
+}
+
+func (l *cacheLoader[T]) groupKey() string {
+	return l.handle.name + ":" + l.key
+}
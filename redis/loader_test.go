@@ -0,0 +1,53 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrLoad(t *testing.T) {
+	m := newTestRedis(t)
+	ctx := context.Background()
+
+	if _, err := GetOrLoad(ctx, "default", "user:1", 0, func(context.Context) (string, error) { return "", nil }); err == nil {
+		t.Fatal("expected error for ttl 0")
+	}
+
+	// 并发未命中只加载一次
+	var calls atomic.Int32
+	loader := func(context.Context) (string, error) {
+		calls.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		return "alice", nil
+	}
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			if v, err := GetOrLoad(ctx, "default", "user:1", 10*time.Second, loader); err != nil || v != "alice" {
+				t.Errorf("GetOrLoad = %q, %v", v, err)
+			}
+		})
+	}
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("loader calls = %d, want 1", n)
+	}
+
+	// 剩余时间低于刷新比例时，多次命中只触发一次后台刷新
+	m.SetTTL("user:1", 500*time.Millisecond)
+	for range 10 {
+		if v, err := GetOrLoad(ctx, "default", "user:1", 10*time.Second, loader); err != nil || v != "alice" {
+			t.Fatalf("GetOrLoad = %q, %v", v, err)
+		}
+	}
+	deadline := time.Now().Add(time.Second)
+	for m.TTL("user:1") < time.Second && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("loader calls after refresh = %d, want 2", n)
+	}
+}
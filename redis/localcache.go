@@ -0,0 +1,399 @@
+package redis
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/restoflife/ql_common/logger"
+	"go.uber.org/zap"
+)
+
+// LocalCache 默认参数
+const (
+	DefaultLocalCacheEntries = 10000                   // 最大条目数
+	DefaultLocalCacheBytes   = 64 << 20                // 最大占用字节数（估算）
+	DefaultLocalCacheTTL     = time.Minute             // 本地条目最长存活时间
+	DefaultLocalCacheChannel = "localcache:invalidate" // 失效广播的频道
+	localEntryOverhead       = 64                      // 每个条目的额外开销估算（字节）
+	localResubscribeInterval = time.Second             // 订阅断开后的重试间隔
+	localInvalidateTimeout   = 3 * time.Second         // 广播失效消息的超时
+)
+
+// localCacheOptions LocalCache 的可选参数
+type localCacheOptions struct {
+	maxEntries int
+	maxBytes   int64
+	ttl        time.Duration
+	channel    string
+}
+
+// LocalCacheOption 是对 LocalCache 的函数式配置
+type LocalCacheOption func(*localCacheOptions)
+
+// WithLocalMaxEntries 设置本地最大条目数（默认 10000），小于等于 0 时使用默认值
+func WithLocalMaxEntries(n int) LocalCacheOption {
+	return func(o *localCacheOptions) {
+		o.maxEntries = n
+	}
+}
+
+// WithLocalMaxBytes 设置本地最大占用字节数（默认 64MB），按 key 与 value 长度估算，小于等于 0 时使用默认值
+func WithLocalMaxBytes(n int64) LocalCacheOption {
+	return func(o *localCacheOptions) {
+		o.maxBytes = n
+	}
+}
+
+// WithLocalTTL 设置本地条目最长存活时间（默认 1 分钟），不会超过 Redis 中的剩余时间，小于等于 0 时使用默认值
+func WithLocalTTL(ttl time.Duration) LocalCacheOption {
+	return func(o *localCacheOptions) {
+		o.ttl = ttl
+	}
+}
+
+// WithInvalidateChannel 设置失效广播的频道，不同用途的缓存使用不同频道可减少无关消息
+func WithInvalidateChannel(channel string) LocalCacheOption {
+	return func(o *localCacheOptions) {
+		o.channel = channel
+	}
+}
+
+// LocalCacheStats 本地缓存统计
+type LocalCacheStats struct {
+	Hits          uint64 // 本地命中次数
+	Misses        uint64 // 本地未命中次数（回源 Redis）
+	Evictions     uint64 // 因容量或过期淘汰的条目数
+	Invalidations uint64 // 收到的失效消息数
+	Entries       int    // 当前条目数
+	Bytes         int64  // 当前占用字节数（估算）
+}
+
+// localEntry 本地缓存条目
+type localEntry struct {
+	key      string
+	value    string
+	size     int64
+	expireAt time.Time
+}
+
+// invalidation 失效广播消息，Keys 为空表示清空全部
+type invalidation struct {
+	Source string   `json:"src"`
+	Keys   []string `json:"keys"`
+}
+
+// LocalCache 两级缓存：进程内 LRU 在前，Redis 在后，适用于读多写少的热点 key
+// 通过本缓存的写入和删除会经 Pub/Sub 广播，其他进程收到后淘汰本地副本；
+// 订阅断开期间可能丢失消息，因此断开或重新订阅时会清空本地缓存
+// 开启副本读时回源可能读到从节点的旧值，需要时使用 WithMaster
+//
+//	cache, err := redis.NewLocalCache("cache", redis.WithLocalTTL(30*time.Second))
+//	defer cache.Close()
+//	v, err := cache.Get(ctx, "config:site")
+type LocalCache struct {
+	handle *Handle
+	opts   localCacheOptions
+	id     string // 当前实例标识，忽略自己发出的失效消息
+
+	mu    sync.Mutex
+	ll    *list.List               // 最近使用的在前
+	items map[string]*list.Element // key -> *localEntry
+	bytes int64
+	gen   uint64 // 每次失效递增，回源期间发生失效时不写入本地
+
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	evictions     atomic.Uint64
+	invalidations atomic.Uint64
+
+	pubsub *redis.PubSub
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewLocalCache 在当前实例上创建两级缓存并订阅失效频道，不再使用时需调用 Close
+func (h *Handle) NewLocalCache(opts ...LocalCacheOption) (*LocalCache, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	o := localCacheOptions{
+		maxEntries: DefaultLocalCacheEntries,
+		maxBytes:   DefaultLocalCacheBytes,
+		ttl:        DefaultLocalCacheTTL,
+		channel:    DefaultLocalCacheChannel,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.maxEntries <= 0 {
+		o.maxEntries = DefaultLocalCacheEntries
+	}
+	if o.maxBytes <= 0 {
+		o.maxBytes = DefaultLocalCacheBytes
+	}
+	if o.ttl <= 0 {
+		o.ttl = DefaultLocalCacheTTL
+	}
+	if o.channel == "" {
+		o.channel = DefaultLocalCacheChannel
+	}
+
+	// 订阅使用主节点客户端
+	ps := h.pick(WithMaster(context.Background())).Subscribe(context.Background(), o.channel)
+	if _, err := ps.Receive(context.Background()); err != nil {
+		_ = ps.Close()
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &LocalCache{
+		handle: h,
+		opts:   o,
+		id:     newToken(),
+		ll:     list.New(),
+		items:  make(map[string]*list.Element),
+		pubsub: ps,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go c.subscribe(ctx)
+	return c, nil
+}
+
+// NewLocalCache 在指定实例上创建两级缓存
+func NewLocalCache(name string, opts ...LocalCacheOption) (*LocalCache, error) {
+	return Use(name).NewLocalCache(opts...)
+}
+
+// Get 依次读取本地缓存与 Redis，Redis 命中时写入本地；key 不存在时返回 redis.Nil
+func (c *LocalCache) Get(ctx context.Context, key string) (string, error) {
+	if v, ok := c.load(key); ok {
+		c.hits.Add(1)
+		return v, nil
+	}
+	c.misses.Add(1)
+
+	c.mu.Lock()
+	gen := c.gen
+	c.mu.Unlock()
+
+	var get *redis.StringCmd
+	var pttl *redis.DurationCmd
+	_, err := c.handle.pick(ctx).Pipelined(ctx, func(p redis.Pipeliner) error {
+		get = p.Get(ctx, key)
+		pttl = p.PTTL(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	ttl := c.opts.ttl
+	if remaining := pttl.Val(); remaining > 0 && remaining < ttl {
+		ttl = remaining
+	}
+	c.store(key, get.Val(), ttl, gen)
+	return get.Val(), nil
+}
+
+// Set 写入 Redis 并更新本地缓存，然后广播失效通知其他进程
+func (c *LocalCache) Set(ctx context.Context, key, value string, expiration time.Duration) error {
+	err := c.handle.pick(WithMaster(ctx)).Set(ctx, key, value, expiration).Err()
+	c.remove(key)
+	if err != nil {
+		return err
+	}
+	ttl := c.opts.ttl
+	if expiration > 0 && expiration < ttl {
+		ttl = expiration
+	}
+	c.mu.Lock()
+	gen := c.gen
+	c.mu.Unlock()
+	c.store(key, value, ttl, gen)
+	return c.publish(ctx, key)
+}
+
+// Del 删除 Redis 与本地缓存中的 key，然后广播失效
+func (c *LocalCache) Del(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	err := c.handle.pick(WithMaster(ctx)).Del(ctx, keys...).Err()
+	c.remove(keys...)
+	if err != nil {
+		return err
+	}
+	return c.publish(ctx, keys...)
+}
+
+// Invalidate 淘汰本地缓存并广播失效，不修改 Redis；用于数据在其他地方直接写入 Redis 的场景
+func (c *LocalCache) Invalidate(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	c.remove(keys...)
+	return c.publish(ctx, keys...)
+}
+
+// Clear 清空所有进程的本地缓存，不修改 Redis
+func (c *LocalCache) Clear(ctx context.Context) error {
+	c.purge()
+	return c.publish(ctx)
+}
+
+// Stats 返回本地缓存统计
+func (c *LocalCache) Stats() LocalCacheStats {
+	c.mu.Lock()
+	entries, bytes := c.ll.Len(), c.bytes
+	c.mu.Unlock()
+	return LocalCacheStats{
+		Hits:          c.hits.Load(),
+		Misses:        c.misses.Load(),
+		Evictions:     c.evictions.Load(),
+		Invalidations: c.invalidations.Load(),
+		Entries:       entries,
+		Bytes:         bytes,
+	}
+}
+
+// Close 取消订阅并清空本地缓存
+func (c *LocalCache) Close() error {
+	c.cancel()
+	err := c.pubsub.Close()
+	<-c.done
+	c.purge()
+	return err
+}
+
+// load 读取本地条目，过期时淘汰
+func (c *LocalCache) load(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return "", false
+	}
+	e := el.Value.(*localEntry)
+	if time.Now().After(e.expireAt) {
+		c.removeElement(el)
+		c.evictions.Add(1)
+		return "", false
+	}
+	c.ll.MoveToFront(el)
+	return e.value, true
+}
+
+// store 写入本地条目，gen 已变化（期间发生过失效）时放弃写入，超出容量时淘汰最久未使用的条目
+func (c *LocalCache) store(key, value string, ttl time.Duration, gen uint64) {
+	size := int64(len(key)+len(value)) + localEntryOverhead
+	if ttl <= 0 || size > c.opts.maxBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.gen != gen {
+		return
+	}
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+	c.items[key] = c.ll.PushFront(&localEntry{key: key, value: value, size: size, expireAt: time.Now().Add(ttl)})
+	c.bytes += size
+	for c.ll.Len() > c.opts.maxEntries || c.bytes > c.opts.maxBytes {
+		c.removeElement(c.ll.Back())
+		c.evictions.Add(1)
+	}
+}
+
+// remove 淘汰本地条目
+func (c *LocalCache) remove(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	for _, key := range keys {
+		if el, ok := c.items[key]; ok {
+			c.removeElement(el)
+		}
+	}
+}
+
+// purge 清空本地缓存
+func (c *LocalCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	c.ll.Init()
+	clear(c.items)
+	c.bytes = 0
+}
+
+func (c *LocalCache) removeElement(el *list.Element) {
+	e := c.ll.Remove(el).(*localEntry)
+	delete(c.items, e.key)
+	c.bytes -= e.size
+}
+
+// publish 广播失效消息，keys 为空表示清空全部
+func (c *LocalCache) publish(ctx context.Context, keys ...string) error {
+	data, err := json.Marshal(invalidation{Source: c.id, Keys: keys})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), localInvalidateTimeout)
+	defer cancel()
+	if err = c.handle.pick(WithMaster(ctx)).Publish(ctx, c.opts.channel, data).Err(); err != nil {
+		logger.Warn("本地缓存失效广播失败", zap.String("name", c.handle.name), zap.Strings("keys", keys), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// subscribe 接收失效消息直到 Close
+func (c *LocalCache) subscribe(ctx context.Context) {
+	defer close(c.done)
+	for {
+		msg, err := c.pubsub.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, redis.ErrClosed) {
+				return
+			}
+			// 断开期间可能丢失失效消息，清空本地缓存
+			c.purge()
+			logger.Warn("本地缓存订阅中断", zap.String("name", c.handle.name), zap.String("channel", c.opts.channel), zap.Error(err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(localResubscribeInterval):
+			}
+			continue
+		}
+
+		switch m := msg.(type) {
+		case *redis.Subscription:
+			// 重新订阅成功
+			c.purge()
+		case *redis.Message:
+			var inv invalidation
+			if err = json.Unmarshal([]byte(m.Payload), &inv); err != nil {
+				logger.Warn("本地缓存失效消息解析失败", zap.String("name", c.handle.name), zap.String("payload", m.Payload), zap.Error(err))
+				continue
+			}
+			if inv.Source == c.id {
+				continue
+			}
+			if len(inv.Keys) == 0 {
+				c.purge()
+			} else {
+				c.remove(inv.Keys...)
+			}
+			c.invalidations.Add(1)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestLocalCache 创建测试用的本地缓存，测试结束时关闭
+func newTestLocalCache(t *testing.T, opts ...LocalCacheOption) *LocalCache {
+	t.Helper()
+	c, err := NewLocalCache("default", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestLocalCacheOptions(t *testing.T) {
+	newTestRedis(t)
+	c := newTestLocalCache(t, WithLocalMaxEntries(-1), WithLocalMaxBytes(0), WithLocalTTL(-time.Second))
+	if c.opts.maxEntries != DefaultLocalCacheEntries || c.opts.maxBytes != DefaultLocalCacheBytes || c.opts.ttl != DefaultLocalCacheTTL {
+		t.Fatalf("unexpected options: %+v", c.opts)
+	}
+	// 非法参数不会导致 panic 或淘汰全部条目
+	if err := c.Set(context.Background(), "a", "1", 0); err != nil {
+		t.Fatal(err)
+	}
+	if s := c.Stats(); s.Entries != 1 || s.Evictions != 0 {
+		t.Fatalf("Stats = %+v", s)
+	}
+}
+
+func TestLocalCacheLRU(t *testing.T) {
+	m := newTestRedis(t)
+	ctx := context.Background()
+	c := newTestLocalCache(t, WithLocalMaxEntries(2))
+	for _, k := range []string{"a", "b", "c"} {
+		m.Set(k, k)
+	}
+
+	for _, k := range []string{"a", "b", "a", "c"} {
+		if v, err := c.Get(ctx, k); err != nil || v != k {
+			t.Fatalf("Get(%s) = %q, %v", k, v, err)
+		}
+	}
+	// b 最久未使用，被淘汰
+	if s := c.Stats(); s.Entries != 2 || s.Evictions != 1 || s.Hits != 1 || s.Misses != 3 {
+		t.Fatalf("Stats = %+v", s)
+	}
+	if _, ok := c.load("b"); ok {
+		t.Fatal("b should have been evicted")
+	}
+	if _, ok := c.load("a"); !ok {
+		t.Fatal("a should still be cached")
+	}
+
+	// Redis 中不存在的 key 不写入本地
+	if _, err := c.Get(ctx, "missing"); !errors.Is(err, redis.Nil) {
+		t.Fatalf("Get(missing) err = %v", err)
+	}
+}
+
+func TestLocalCacheTTL(t *testing.T) {
+	m := newTestRedis(t)
+	ctx := context.Background()
+	c := newTestLocalCache(t, WithLocalTTL(20*time.Millisecond))
+	m.Set("a", "1")
+
+	if _, err := c.Get(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	// 本地过期后回源读到 Redis 中的新值
+	m.Set("a", "2")
+	if v, _ := c.Get(ctx, "a"); v != "1" {
+		t.Fatalf("Get before expiry = %q, want cached 1", v)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if v, _ := c.Get(ctx, "a"); v != "2" {
+		t.Fatalf("Get after expiry = %q, want 2", v)
+	}
+}
+
+func TestLocalCacheInvalidation(t *testing.T) {
+	newTestRedis(t)
+	ctx := context.Background()
+	a := newTestLocalCache(t)
+	if err := a.Set(ctx, "k", "1", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	b := newTestLocalCache(t)
+	if v, err := b.Get(ctx, "k"); err != nil || v != "1" {
+		t.Fatalf("Get = %q, %v", v, err)
+	}
+
+	// 另一个实例写入后广播失效，本地副本被淘汰
+	if err := a.Set(ctx, "k", "2", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	waitInvalidations(t, b, 1)
+	if v, _ := b.Get(ctx, "k"); v != "2" {
+		t.Fatalf("Get after invalidation = %q, want 2", v)
+	}
+
+	// Clear 清空所有实例的本地缓存
+	if err := a.Clear(ctx); err != nil {
+		t.Fatal(err)
+	}
+	waitInvalidations(t, b, 2)
+	if s := b.Stats(); s.Entries != 0 {
+		t.Fatalf("Stats after Clear = %+v", s)
+	}
+	// 自己发出的消息被忽略
+	if s := a.Stats(); s.Invalidations != 0 {
+		t.Fatalf("self invalidations = %d", s.Invalidations)
+	}
+}
+
+// waitInvalidations 等待收到 n 条失效消息
+func waitInvalidations(t *testing.T, c *LocalCache, n uint64) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for c.Stats().Invalidations < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("invalidations = %d, want %d", c.Stats().Invalidations, n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,345 @@
+package redis
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/restoflife/ql_common/logger"
+	"go.uber.org/zap"
+)
+
+// 分布式锁默认参数
+const (
+	DefaultLockTTL        = 30 * time.Second
+	DefaultLockMinBackoff = 20 * time.Millisecond
+	DefaultLockMaxBackoff = time.Second
+)
+
+var (
+	// ErrLockNotObtained 锁已被其他持有者占用
+	ErrLockNotObtained = errors.New("redis: lock not obtained")
+	// ErrLockNotHeld 解锁或续期时锁已不属于当前持有者（已过期或被释放）
+	ErrLockNotHeld = errors.New("redis: lock not held")
+)
+
+// lockScript 加锁：锁不存在时占用并生成新的 fencing token，持有者相同时重入计数加一
+// 成功返回 {fence, 0}，失败返回 {0, 剩余毫秒数}
+var lockScript = redis.NewScript(`
+local owner = redis.call('HGET', KEYS[1], 'owner')
+if not owner then
+	local fence = redis.call('INCR', KEYS[2])
+	redis.call('HSET', KEYS[1], 'owner', ARGV[1], 'count', 1, 'fence', fence)
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return {fence, 0}
+end
+if owner == ARGV[1] then
+	redis.call('HINCRBY', KEYS[1], 'count', 1)
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return {tonumber(redis.call('HGET', KEYS[1], 'fence')), 0}
+end
+return {0, redis.call('PTTL', KEYS[1])}
+`)
+
+// unlockScript 解锁：只有持有者可以解锁，重入计数归零时删除锁
+// 返回剩余重入次数，-1 表示锁不属于该持有者
+var unlockScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'owner') ~= ARGV[1] then
+	return -1
+end
+local n = redis.call('HINCRBY', KEYS[1], 'count', -1)
+if n > 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return n
+end
+redis.call('DEL', KEYS[1])
+return 0
+`)
+
+// extendScript 续期：只有持有者可以续期
+var extendScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'owner') == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// lockOptions 分布式锁的可选参数
+type lockOptions struct {
+	ttl        time.Duration
+	owner      string
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	watchdog   bool
+}
+
+// LockOption 是对分布式锁的函数式配置
+type LockOption func(*lockOptions)
+
+// WithLockTTL 设置锁的过期时间，开启看门狗时每 ttl/3 续期一次；小于 3ms 时使用 DefaultLockTTL
+func WithLockTTL(ttl time.Duration) LockOption {
+	return func(o *lockOptions) {
+		o.ttl = ttl
+	}
+}
+
+// WithLockOwner 指定持有者标识，相同标识的多个 Lock 可重入同一把锁；默认每个 Lock 随机生成
+func WithLockOwner(owner string) LockOption {
+	return func(o *lockOptions) {
+		o.owner = owner
+	}
+}
+
+// WithLockBackoff 设置阻塞加锁时的重试退避区间
+func WithLockBackoff(min, max time.Duration) LockOption {
+	return func(o *lockOptions) {
+		o.minBackoff, o.maxBackoff = min, max
+	}
+}
+
+// WithoutWatchdog 关闭看门狗，锁在 ttl 到期后自动释放
+func WithoutWatchdog() LockOption {
+	return func(o *lockOptions) {
+		o.watchdog = false
+	}
+}
+
+// Lock 基于 Redis 的分布式锁，支持按持有者重入、看门狗自动续期与递增的 fencing token
+// key 为 lock:{<key>}，token 计数器为 lock:{<key>}:fence，集群模式下位于同一槽位
+//
+//	l := redis.Use("default").NewLock("order:1001")
+//	if err := l.Lock(ctx); err != nil {
+//		return err
+//	}
+//	defer l.Unlock(context.Background())
+//	// 下游写入时携带 l.Fence()，拒绝小于已见过的 token 的请求
+type Lock struct {
+	handle   *Handle
+	key      string
+	fenceKey string
+	opts     lockOptions
+
+	mu    sync.Mutex
+	held  int           // 本对象持有的重入次数
+	fence int64         // 当前 fencing token
+	stop  chan struct{} // 停止看门狗
+	lost  chan struct{} // 看门狗续期失败（锁已丢失）时关闭
+}
+
+// NewLock 创建分布式锁（不会立即加锁）
+func (h *Handle) NewLock(key string, opts ...LockOption) *Lock {
+	o := lockOptions{
+		ttl:        DefaultLockTTL,
+		minBackoff: DefaultLockMinBackoff,
+		maxBackoff: DefaultLockMaxBackoff,
+		watchdog:   true,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	// 过短的 ttl 会使看门狗间隔为 0 或以 PEXPIRE 0 删除锁，使用默认值
+	if o.ttl < 3*time.Millisecond {
+		o.ttl = DefaultLockTTL
+	}
+	if o.owner == "" {
+		o.owner = newToken()
+	}
+	return &Lock{
+		handle:   h,
+		key:      "lock:{" + key + "}",
+		fenceKey: "lock:{" + key + "}:fence",
+		opts:     o,
+		lost:     make(chan struct{}),
+	}
+}
+
+// NewLock 创建指定实例上的分布式锁
+func NewLock(name, key string, opts ...LockOption) *Lock {
+	return Use(name).NewLock(key, opts...)
+}
+
+// Owner 返回持有者标识
+func (l *Lock) Owner() string {
+	return l.opts.owner
+}
+
+// Fence 返回最近一次加锁获得的 fencing token，每次锁被重新占用时递增，重入不变
+func (l *Lock) Fence() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.fence
+}
+
+// Lost 返回看门狗续期失败时关闭的通道，持有者应停止依赖该锁的操作
+func (l *Lock) Lost() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lost
+}
+
+// TryLock 尝试加锁一次，锁被占用时返回 ErrLockNotObtained
+func (l *Lock) TryLock(ctx context.Context) error {
+	_, err := l.tryLock(ctx)
+	return err
+}
+
+// Lock 阻塞加锁，按退避区间重试直到成功或 ctx 结束
+func (l *Lock) Lock(ctx context.Context) error {
+	backoff := max(l.opts.minBackoff, time.Millisecond)
+	for {
+		wait, err := l.tryLock(ctx)
+		if !errors.Is(err, ErrLockNotObtained) {
+			return err
+		}
+
+		// 等待时间不超过锁的剩余时间，加入随机抖动避免同时重试
+		d := backoff/2 + rand.N(backoff/2+1)
+		if wait > 0 && wait < d {
+			d = wait
+		}
+		timer := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if backoff *= 2; backoff > l.opts.maxBackoff {
+			backoff = l.opts.maxBackoff
+		}
+	}
+}
+
+// tryLock 加锁一次，失败时返回锁的剩余时间
+func (l *Lock) tryLock(ctx context.Context) (time.Duration, error) {
+	if l.handle.err != nil {
+		return 0, l.handle.err
+	}
+	res, err := lockScript.Run(ctx, l.handle.primary(), []string{l.key, l.fenceKey},
+		l.opts.owner, l.opts.ttl.Milliseconds()).Int64Slice()
+	if err != nil {
+		return 0, err
+	}
+	if res[0] == 0 {
+		return time.Duration(res[1]) * time.Millisecond, ErrLockNotObtained
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fence = res[0]
+	l.held++
+	if l.held == 1 && l.opts.watchdog {
+		// 上次持有期间锁已丢失时，为本次持有创建新的通知通道
+		select {
+		case <-l.lost:
+			l.lost = make(chan struct{})
+		default:
+		}
+		l.stop = make(chan struct{})
+		go l.watch(l.stop)
+	}
+	return 0, nil
+}
+
+// Unlock 解锁一次，重入计数归零时释放锁；锁已不属于当前持有者时返回 ErrLockNotHeld
+func (l *Lock) Unlock(ctx context.Context) error {
+	if l.handle.err != nil {
+		return l.handle.err
+	}
+	n, err := unlockScript.Run(ctx, l.handle.primary(), []string{l.key},
+		l.opts.owner, l.opts.ttl.Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held > 0 {
+		l.held--
+	}
+	if n <= 0 || l.held == 0 {
+		l.held = 0
+		if l.stop != nil {
+			close(l.stop)
+			l.stop = nil
+		}
+	}
+	if n < 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// Extend 手动续期为完整的 ttl
+func (l *Lock) Extend(ctx context.Context) error {
+	if l.handle.err != nil {
+		return l.handle.err
+	}
+	ok, err := extendScript.Run(ctx, l.handle.primary(), []string{l.key},
+		l.opts.owner, l.opts.ttl.Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// watch 看门狗：每 ttl/3 续期一次，锁丢失或 ttl 内一直续期失败时关闭 lost
+func (l *Lock) watch(stop chan struct{}) {
+	interval := l.opts.ttl / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	lastOK := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		err := l.Extend(ctx)
+		cancel()
+		switch {
+		case err == nil:
+			lastOK = time.Now()
+			continue
+		case errors.Is(err, ErrLockNotHeld):
+		case time.Since(lastOK) < l.opts.ttl:
+			// 网络抖动时继续重试，直到锁可能已过期
+			continue
+		}
+
+		l.mu.Lock()
+		// 续期期间已解锁或重新加锁时，该看门狗已过期，不影响新的持有周期
+		if l.stop != stop {
+			l.mu.Unlock()
+			return
+		}
+		l.held = 0
+		l.stop = nil
+		close(l.lost)
+		l.mu.Unlock()
+
+		logger.Warn("Redis分布式锁续期失败，锁已丢失",
+			zap.String("name", l.handle.name),
+			zap.String("key", l.key),
+			zap.Error(err),
+		)
+		return
+	}
+}
+
+// newToken 生成随机的持有者标识
+func newToken() string {
+	b := make([]byte, 16)
+	_, _ = crand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,172 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLockReentrant(t *testing.T) {
+	m := newTestRedis(t)
+	ctx := context.Background()
+	a := NewLock("default", "order:1", WithLockOwner("a"), WithoutWatchdog())
+	b := NewLock("default", "order:1", WithLockOwner("b"), WithoutWatchdog())
+
+	if err := a.TryLock(ctx); err != nil {
+		t.Fatalf("TryLock = %v", err)
+	}
+	fence := a.Fence()
+	// 相同持有者重入，fencing token 不变
+	if err := a.TryLock(ctx); err != nil || a.Fence() != fence {
+		t.Fatalf("reentrant TryLock = %v, fence %d -> %d", err, fence, a.Fence())
+	}
+	if err := b.TryLock(ctx); !errors.Is(err, ErrLockNotObtained) {
+		t.Fatalf("TryLock by other owner = %v", err)
+	}
+	if err := b.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("Unlock by other owner = %v", err)
+	}
+
+	// 重入两次需要解锁两次
+	if err := a.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Exists("lock:{order:1}") {
+		t.Fatal("lock released before reentrant count reached zero")
+	}
+	if err := a.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("Unlock after release = %v", err)
+	}
+
+	// 锁被重新占用时 fencing token 递增
+	if err := b.TryLock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if b.Fence() <= fence {
+		t.Fatalf("fence = %d, want > %d", b.Fence(), fence)
+	}
+}
+
+func TestLockExtend(t *testing.T) {
+	m := newTestRedis(t)
+	ctx := context.Background()
+	l := NewLock("default", "order:1", WithLockTTL(time.Second), WithoutWatchdog())
+
+	if err := l.Extend(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("Extend before lock = %v", err)
+	}
+	if err := l.TryLock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	m.FastForward(800 * time.Millisecond)
+	if err := l.Extend(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := m.TTL("lock:{order:1}"); ttl != time.Second {
+		t.Fatalf("ttl = %v, want 1s", ttl)
+	}
+
+	// 过期后锁已不属于该持有者
+	m.FastForward(2 * time.Second)
+	if err := l.Extend(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("Extend after expiry = %v", err)
+	}
+	if err := l.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("Unlock after expiry = %v", err)
+	}
+}
+
+func TestLockMinTTL(t *testing.T) {
+	m := newTestRedis(t)
+	l := NewLock("default", "order:1", WithLockTTL(time.Millisecond), WithoutWatchdog())
+	if err := l.TryLock(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := m.TTL("lock:{order:1}"); ttl != DefaultLockTTL {
+		t.Fatalf("ttl = %v, want %v", ttl, DefaultLockTTL)
+	}
+}
+
+func TestLockWait(t *testing.T) {
+	newTestRedis(t)
+	ctx := context.Background()
+	a := NewLock("default", "order:1", WithoutWatchdog())
+	b := NewLock("default", "order:1", WithLockBackoff(time.Millisecond, 5*time.Millisecond), WithoutWatchdog())
+
+	if err := a.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := b.Lock(timeoutCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Lock while held = %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- b.Lock(ctx) }()
+	time.Sleep(10 * time.Millisecond)
+	if err := a.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Lock did not acquire after release")
+	}
+}
+
+func TestLockWatchdog(t *testing.T) {
+	m := newTestRedis(t)
+	ctx := context.Background()
+	l := NewLock("default", "order:1", WithLockTTL(30*time.Millisecond))
+
+	if err := l.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// 看门狗持续续期
+	time.Sleep(50 * time.Millisecond)
+	if ttl := m.TTL("lock:{order:1}"); ttl != 30*time.Millisecond {
+		t.Fatalf("ttl = %v, want 30ms", ttl)
+	}
+
+	// 锁被外部删除后通知丢失
+	lost := l.Lost()
+	m.Del("lock:{order:1}")
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Fatal("Lost() not closed after the key was deleted")
+	}
+
+	// 解锁后重新加锁，旧的看门狗不影响新的持有周期
+	for i := 0; i < 20; i++ {
+		if err := l.Lock(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Unlock(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case <-l.Lost():
+		t.Fatal("Lost() closed while the lock is held")
+	default:
+	}
+	if err := l.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if m.Exists("lock:{order:1}") {
+		t.Fatal("lock still exists after Unlock")
+	}
+}
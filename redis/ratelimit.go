@@ -0,0 +1,202 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/restoflife/ql_common/logger"
+)
+
+// RateLimitResult 限流判定结果，与 logger.RateLimit 中间件共用
+type RateLimitResult = logger.RateLimitResult
+
+// 以下脚本均使用 Redis 服务器时间，各实例之间不受本地时钟偏差影响
+// 返回 {是否放行, 剩余配额, 重试等待时间, 恢复时间}，GCRA 的时间单位为微秒，其余为毫秒
+
+// slidingWindowScript 滑动窗口日志：ZSET 记录窗口内每次请求的时间
+var slidingWindowScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = t[1] * 1000 + math.floor(t[2] / 1000)
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+if count + n > limit then
+	local idx = count + n - limit - 1
+	local oldest = redis.call('ZRANGE', KEYS[1], idx, idx, 'WITHSCORES')
+	local retry = tonumber(oldest[2]) + window - now
+	local first = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+	return {0, limit - count, retry, tonumber(first[2]) + window - now}
+end
+for i = 1, n do
+	redis.call('ZADD', KEYS[1], now, ARGV[4] .. ':' .. i)
+end
+redis.call('PEXPIRE', KEYS[1], window)
+local first = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+return {1, limit - count - n, 0, tonumber(first[2]) + window - now}
+`)
+
+// fixedWindowScript 固定窗口计数：窗口从第一次请求开始，到期后整体重置
+var fixedWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+local cur = tonumber(redis.call('GET', KEYS[1]) or '0')
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl < 0 then
+	cur = 0
+	ttl = window
+end
+if cur + n > limit then
+	return {0, limit - cur, ttl, ttl}
+end
+if cur == 0 then
+	redis.call('SET', KEYS[1], n, 'PX', window)
+else
+	redis.call('INCRBY', KEYS[1], n)
+end
+return {1, limit - cur - n, 0, ttl}
+`)
+
+// gcraScript GCRA（通用信元速率算法，等价于令牌桶）：只保存理论到达时间 TAT（微秒）
+var gcraScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = t[1] * 1000000 + t[2]
+local interval = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+local tolerance = interval * burst
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+local newTat = tat + interval * n
+local diff = now - (newTat - tolerance)
+if diff < 0 then
+	local remaining = math.floor((now - (tat - tolerance)) / interval)
+	return {0, remaining, -diff, tat - now}
+end
+redis.call('SET', KEYS[1], string.format('%d', newTat), 'PX', math.max(1, math.ceil((newTat - now) / 1000)))
+return {1, math.floor(diff / interval), 0, newTat - now}
+`)
+
+// SlidingWindowLimiter 滑动窗口日志限流：任意 window 时长内最多 limit 次，精确但每个请求占用一条记录
+type SlidingWindowLimiter struct {
+	handle *Handle
+	prefix string
+	limit  int64
+	window time.Duration
+}
+
+// NewSlidingWindowLimiter 创建滑动窗口限流器，key 为 <prefix>:<限流 key>
+// limit 小于 1 时按 1 处理；窗口以毫秒精度计算，最小为 1 毫秒
+func (h *Handle) NewSlidingWindowLimiter(prefix string, limit int64, window time.Duration) *SlidingWindowLimiter {
+	return &SlidingWindowLimiter{handle: h, prefix: prefix, limit: max(limit, 1), window: max(window, time.Millisecond)}
+}
+
+// NewSlidingWindowLimiter 创建指定实例上的滑动窗口限流器
+func NewSlidingWindowLimiter(name, prefix string, limit int64, window time.Duration) *SlidingWindowLimiter {
+	return Use(name).NewSlidingWindowLimiter(prefix, limit, window)
+}
+
+// Allow 判定一次请求
+func (l *SlidingWindowLimiter) Allow(ctx context.Context, key string) (*RateLimitResult, error) {
+	return l.AllowN(ctx, key, 1)
+}
+
+// AllowN 判定 n 次请求（全部放行或全部拒绝）
+func (l *SlidingWindowLimiter) AllowN(ctx context.Context, key string, n int64) (*RateLimitResult, error) {
+	return runLimiter(ctx, l.handle, slidingWindowScript, l.prefix+":"+key, l.limit, n, time.Millisecond,
+		l.limit, l.window.Milliseconds(), n, newToken())
+}
+
+// FixedWindowLimiter 固定窗口限流：每个窗口最多 limit 次，开销最小，窗口边界处可能出现两倍突发
+type FixedWindowLimiter struct {
+	handle *Handle
+	prefix string
+	limit  int64
+	window time.Duration
+}
+
+// NewFixedWindowLimiter 创建固定窗口限流器，key 为 <prefix>:<限流 key>
+// limit 小于 1 时按 1 处理；窗口以毫秒精度计算，最小为 1 毫秒
+func (h *Handle) NewFixedWindowLimiter(prefix string, limit int64, window time.Duration) *FixedWindowLimiter {
+	return &FixedWindowLimiter{handle: h, prefix: prefix, limit: max(limit, 1), window: max(window, time.Millisecond)}
+}
+
+// NewFixedWindowLimiter 创建指定实例上的固定窗口限流器
+func NewFixedWindowLimiter(name, prefix string, limit int64, window time.Duration) *FixedWindowLimiter {
+	return Use(name).NewFixedWindowLimiter(prefix, limit, window)
+}
+
+// Allow 判定一次请求
+func (l *FixedWindowLimiter) Allow(ctx context.Context, key string) (*RateLimitResult, error) {
+	return l.AllowN(ctx, key, 1)
+}
+
+// AllowN 判定 n 次请求（全部放行或全部拒绝）
+func (l *FixedWindowLimiter) AllowN(ctx context.Context, key string, n int64) (*RateLimitResult, error) {
+	return runLimiter(ctx, l.handle, fixedWindowScript, l.prefix+":"+key, l.limit, n, time.Millisecond,
+		l.limit, l.window.Milliseconds(), n)
+}
+
+// GCRALimiter GCRA 限流（令牌桶）：平均每 period 允许 rate 次，最多突发 burst 次，只占用一个 key
+type GCRALimiter struct {
+	handle   *Handle
+	prefix   string
+	interval time.Duration
+	burst    int64
+}
+
+// NewGCRALimiter 创建 GCRA 限流器，key 为 <prefix>:<限流 key>；burst 小于 1 时按 1 处理
+// 请求间隔 period/rate 以微秒精度计算，最小为 1 微秒（即每秒最多 100 万次）
+func (h *Handle) NewGCRALimiter(prefix string, rate int64, period time.Duration, burst int64) *GCRALimiter {
+	interval := period / time.Duration(max(rate, 1))
+	return &GCRALimiter{handle: h, prefix: prefix, interval: max(interval, time.Microsecond), burst: max(burst, 1)}
+}
+
+// NewGCRALimiter 创建指定实例上的 GCRA 限流器
+func NewGCRALimiter(name, prefix string, rate int64, period time.Duration, burst int64) *GCRALimiter {
+	return Use(name).NewGCRALimiter(prefix, rate, period, burst)
+}
+
+// Allow 判定一次请求
+func (l *GCRALimiter) Allow(ctx context.Context, key string) (*RateLimitResult, error) {
+	return l.AllowN(ctx, key, 1)
+}
+
+// AllowN 判定 n 次请求（全部放行或全部拒绝）
+func (l *GCRALimiter) AllowN(ctx context.Context, key string, n int64) (*RateLimitResult, error) {
+	return runLimiter(ctx, l.handle, gcraScript, l.prefix+":"+key, l.burst, n, time.Microsecond,
+		l.interval.Microseconds(), l.burst, n)
+}
+
+// runLimiter 执行限流脚本并解析结果，unit 为脚本返回的时间单位
+func runLimiter(ctx context.Context, h *Handle, script *redis.Script, key string, limit, n int64, unit time.Duration, args ...any) (*RateLimitResult, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	if n < 1 || n > limit {
+		return nil, fmt.Errorf("redis: rate limit n must be between 1 and %d", limit)
+	}
+	res, err := script.Run(ctx, h.primary(), []string{key}, args...).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	return &RateLimitResult{
+		Allowed:    res[0] == 1,
+		Limit:      limit,
+		Remaining:  res[1],
+		RetryAfter: time.Duration(res[2]) * unit,
+		ResetAfter: time.Duration(res[3]) * unit,
+	}, nil
+}
+
+var (
+	_ logger.RateLimiter = (*SlidingWindowLimiter)(nil)
+	_ logger.RateLimiter = (*FixedWindowLimiter)(nil)
+	_ logger.RateLimiter = (*GCRALimiter)(nil)
+)
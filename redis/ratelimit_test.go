@@ -0,0 +1,109 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGCRALimiter(t *testing.T) {
+	m := newTestRedis(t)
+	m.SetTime(time.Now()) // 固定服务器时间
+	ctx := context.Background()
+
+	// 每秒 3 次，间隔 333.333ms 不能被截断为整毫秒后放大误差
+	l := NewGCRALimiter("default", "gcra", 3, time.Second, 3)
+	if l.interval.Microseconds() != 333333 {
+		t.Fatalf("interval = %s", l.interval)
+	}
+	for i := 0; i < 3; i++ {
+		r, err := l.Allow(ctx, "u1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !r.Allowed || r.Remaining != int64(2-i) {
+			t.Fatalf("request %d: %+v", i, r)
+		}
+	}
+	r, err := l.Allow(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Allowed || r.RetryAfter <= 0 || r.RetryAfter > 334*time.Millisecond {
+		t.Fatalf("over limit: %+v", r)
+	}
+
+	// 每秒 5000 次的间隔小于 1ms，仍按实际速率限流
+	fast := NewGCRALimiter("default", "gcra", 5000, time.Second, 1)
+	if fast.interval != 200*time.Microsecond {
+		t.Fatalf("interval = %s", fast.interval)
+	}
+	if r, err = fast.Allow(ctx, "u2"); err != nil || !r.Allowed {
+		t.Fatalf("first request: %+v, %v", r, err)
+	}
+	if r, err = fast.Allow(ctx, "u2"); err != nil || r.Allowed || r.RetryAfter > 200*time.Microsecond {
+		t.Fatalf("second request: %+v, %v", r, err)
+	}
+}
+
+func TestSlidingWindowLimiter(t *testing.T) {
+	m := newTestRedis(t)
+	now := time.Now()
+	m.SetTime(now)
+	ctx := context.Background()
+
+	l := NewSlidingWindowLimiter("default", "sw", 2, time.Second)
+	for i := 0; i < 2; i++ {
+		if r, err := l.Allow(ctx, "u1"); err != nil || !r.Allowed || r.Remaining != int64(1-i) {
+			t.Fatalf("request %d: %+v, %v", i, r, err)
+		}
+	}
+	r, err := l.Allow(ctx, "u1")
+	if err != nil || r.Allowed || r.RetryAfter != time.Second {
+		t.Fatalf("over limit: %+v, %v", r, err)
+	}
+
+	// 最早的请求滑出窗口后放行
+	m.SetTime(now.Add(time.Second))
+	if r, err = l.Allow(ctx, "u1"); err != nil || !r.Allowed {
+		t.Fatalf("after window: %+v, %v", r, err)
+	}
+}
+
+func TestFixedWindowLimiter(t *testing.T) {
+	m := newTestRedis(t)
+	ctx := context.Background()
+
+	l := NewFixedWindowLimiter("default", "fw", 2, time.Second)
+	if r, err := l.AllowN(ctx, "u1", 2); err != nil || !r.Allowed || r.Remaining != 0 {
+		t.Fatalf("AllowN: %+v, %v", r, err)
+	}
+	r, err := l.Allow(ctx, "u1")
+	if err != nil || r.Allowed || r.RetryAfter != time.Second {
+		t.Fatalf("over limit: %+v, %v", r, err)
+	}
+
+	// 窗口到期后整体重置
+	m.FastForward(time.Second)
+	if r, err = l.Allow(ctx, "u1"); err != nil || !r.Allowed || r.Remaining != 1 {
+		t.Fatalf("after window: %+v, %v", r, err)
+	}
+}
+
+func TestWindowLimiterBounds(t *testing.T) {
+	newTestRedis(t)
+	ctx := context.Background()
+
+	// 非法的 limit 与过短的窗口被修正，不会发送 PX 0 或拒绝所有请求
+	sw := NewSlidingWindowLimiter("default", "sw", 0, time.Microsecond)
+	fw := NewFixedWindowLimiter("default", "fw", -1, 0)
+	if sw.limit != 1 || sw.window != time.Millisecond || fw.limit != 1 || fw.window != time.Millisecond {
+		t.Fatalf("sliding = %d/%s, fixed = %d/%s", sw.limit, sw.window, fw.limit, fw.window)
+	}
+	if r, err := sw.Allow(ctx, "u1"); err != nil || !r.Allowed {
+		t.Fatalf("sliding: %+v, %v", r, err)
+	}
+	if r, err := fw.Allow(ctx, "u1"); err != nil || !r.Allowed {
+		t.Fatalf("fixed: %+v, %v", r, err)
+	}
+}
@@ -0,0 +1,48 @@
+package redis
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/restoflife/ql_common/logger"
+)
+
+func TestMain(m *testing.M) {
+	// 看门狗、订阅等后台任务会写默认日志
+	dir, err := os.MkdirTemp("", "redis-test")
+	if err != nil {
+		panic(err)
+	}
+	logger.New(&logger.Config{Filename: filepath.Join(dir, "test.log")})
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// newTestRedis 启动 miniredis 并注册为 default 实例，测试结束时移除
+func newTestRedis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	m := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: m.Addr()})
+	redisMgr["default"] = client
+	t.Cleanup(func() {
+		delete(redisMgr, "default")
+		_ = client.Close()
+	})
+	return m
+}
+
+func TestConfigUnknownMode(t *testing.T) {
+	// 未知模式按单机模式处理，仍需配置 addr
+	c := &Config{Mode: "single", Addr: "127.0.0.1:6379"}
+	if err := c.validate(); err != nil {
+		t.Fatal(err)
+	}
+	c.Addr = ""
+	if err := c.validate(); err == nil {
+		t.Fatal("expected addr error")
+	}
+}
@@ -0,0 +1,146 @@
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 开启副本读的实例对应的仅主节点客户端
+var masterMgr = map[string]redis.UniversalClient{}
+
+// masterKey context 中标记强制读主节点的 key
+type masterKey struct{}
+
+// WithMaster 返回强制访问主节点的 context，写入后需立即读到最新数据时使用；未开启副本读的实例不受影响
+// TxPipeline、Watch、Eval 等脚本方法以及分布式锁、限流始终在主节点执行，无需标记
+//
+//	v, err := redis.Use("cache").Get(redis.WithMaster(ctx), key)
+func WithMaster(ctx context.Context) context.Context {
+	return context.WithValue(ctx, masterKey{}, true)
+}
+
+// isMaster 判断 context 是否要求访问主节点
+func isMaster(ctx context.Context) bool {
+	v, _ := ctx.Value(masterKey{}).(bool)
+	return v
+}
+
+// GetMaster 获取只访问主节点的客户端，未开启副本读时与 GetRedis 相同
+func GetMaster(name string) (redis.UniversalClient, error) {
+	if client, ok := masterMgr[name]; ok {
+		return client, nil
+	}
+	return GetRedis(name)
+}
+
+// replicaReads 是否将只读命令发送到从节点
+func (c *Config) replicaReads() bool {
+	switch c.Mode {
+	case SENTINEL:
+		return c.ReplicaOnly || c.RouteByLatency || c.RouteRandomly
+	case CLUSTER:
+		return c.ReadOnly || c.RouteByLatency || c.RouteRandomly
+	}
+	return false
+}
+
+// validateReplica 校验副本读配置
+func (c *Config) validateReplica() error {
+	switch c.Mode {
+	case SENTINEL:
+		if c.ReadOnly {
+			return fmt.Errorf("read_only is only valid in cluster mode, use replica_only in sentinel mode")
+		}
+	case CLUSTER:
+		if c.ReplicaOnly {
+			return fmt.Errorf("replica_only is only valid in sentinel mode, use read_only in cluster mode")
+		}
+	default:
+		if c.ReplicaOnly || c.ReadOnly || c.RouteByLatency || c.RouteRandomly {
+			return fmt.Errorf("replica reads require sentinel or cluster mode")
+		}
+	}
+	if c.RouteByLatency && c.RouteRandomly {
+		return fmt.Errorf("route_by_latency and route_randomly are mutually exclusive")
+	}
+	return nil
+}
+
+// newClient 按模式创建客户端，replica 为 true 时只读命令按配置路由到从节点
+func (c *Config) newClient(tlsConfig *tls.Config, replica bool) redis.UniversalClient {
+	switch c.Mode {
+	case SENTINEL:
+		// 使用 Redis Sentinel 模式
+		opt := &redis.FailoverOptions{
+			MasterName:       c.MasterName,
+			SentinelAddrs:    c.Slaves,
+			SentinelUsername: c.SentinelUsername,
+			SentinelPassword: c.SentinelPassword,
+			Username:         c.Username,
+			Password:         c.Password,
+			DB:               c.DB,
+			PoolSize:         c.PoolSize,
+			MinIdleConns:     c.MinIdle,
+			TLSConfig:        tlsConfig,
+			DialTimeout:      duration(c.DialTimeout),
+			ReadTimeout:      duration(c.ReadTimeout),
+			WriteTimeout:     duration(c.WriteTimeout),
+			PoolTimeout:      duration(c.PoolTimeout),
+			MaxRetries:       c.MaxRetries,
+			MinRetryBackoff:  duration(c.MinRetryBackoff),
+			MaxRetryBackoff:  duration(c.MaxRetryBackoff),
+		}
+		if !replica {
+			return redis.NewFailoverClient(opt)
+		}
+		// 副本读通过 FailoverClusterClient 实现，写命令仍发送到主节点
+		opt.ReplicaOnly = c.ReplicaOnly
+		opt.RouteByLatency = c.RouteByLatency
+		opt.RouteRandomly = c.RouteRandomly
+		return redis.NewFailoverClusterClient(opt)
+	case CLUSTER:
+		// 使用 Redis Cluster 模式
+		opt := &redis.ClusterOptions{
+			Addrs:           c.Slaves,
+			Username:        c.Username,
+			Password:        c.Password,
+			PoolSize:        c.PoolSize,
+			MinIdleConns:    c.MinIdle,
+			TLSConfig:       tlsConfig,
+			DialTimeout:     duration(c.DialTimeout),
+			ReadTimeout:     duration(c.ReadTimeout),
+			WriteTimeout:    duration(c.WriteTimeout),
+			PoolTimeout:     duration(c.PoolTimeout),
+			MaxRetries:      c.MaxRetries,
+			MinRetryBackoff: duration(c.MinRetryBackoff),
+			MaxRetryBackoff: duration(c.MaxRetryBackoff),
+		}
+		if replica {
+			opt.ReadOnly = true
+			opt.RouteByLatency = c.RouteByLatency
+			opt.RouteRandomly = c.RouteRandomly
+		}
+		return redis.NewClusterClient(opt)
+	default:
+		// 默认使用 Standalone 模式
+		return redis.NewClient(&redis.Options{
+			Addr:            c.Addr,
+			Username:        c.Username,
+			Password:        c.Password,
+			DB:              c.DB,
+			PoolSize:        c.PoolSize,
+			MinIdleConns:    c.MinIdle,
+			TLSConfig:       tlsConfig,
+			DialTimeout:     duration(c.DialTimeout),
+			ReadTimeout:     duration(c.ReadTimeout),
+			WriteTimeout:    duration(c.WriteTimeout),
+			PoolTimeout:     duration(c.PoolTimeout),
+			MaxRetries:      c.MaxRetries,
+			MinRetryBackoff: duration(c.MinRetryBackoff),
+			MaxRetryBackoff: duration(c.MaxRetryBackoff),
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestReplicaRouting(t *testing.T) {
+	// 路由客户端指向 replica，仅主节点客户端指向 master，通过数据所在的实例判断命令去向
+	replica, master := miniredis.RunT(t), miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: replica.Addr()})
+	primary := redis.NewClient(&redis.Options{Addr: master.Addr()})
+	redisMgr["replica_test"], masterMgr["replica_test"] = client, primary
+	t.Cleanup(func() {
+		delete(redisMgr, "replica_test")
+		delete(masterMgr, "replica_test")
+		_ = client.Close()
+		_ = primary.Close()
+	})
+	replica.Set("k", "replica")
+	master.Set("k", "master")
+	ctx := context.Background()
+	h := Use("replica_test")
+
+	if v, err := h.Get(ctx, "k"); err != nil || v != "replica" {
+		t.Fatalf("Get = %q, %v", v, err)
+	}
+	if v, err := h.Get(WithMaster(ctx), "k"); err != nil || v != "master" {
+		t.Fatalf("Get with master = %q, %v", v, err)
+	}
+	if c, err := GetMaster("replica_test"); err != nil || c != primary {
+		t.Fatalf("GetMaster = %v, %v", c, err)
+	}
+
+	// 事务、WATCH、脚本与锁始终在主节点执行
+	if err := h.TxPipeline(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, "tx", "1", 0)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Watch(ctx, func(tx *redis.Tx) error {
+		return tx.Set(ctx, "watch", "1", 0).Err()
+	}, "watch"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Eval(ctx, "return redis.call('SET', KEYS[1], '1')", []string{"eval"}); err != nil {
+		t.Fatal(err)
+	}
+	lock := h.NewLock("lock")
+	if err := lock.TryLock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Unlock(ctx)
+	for _, key := range []string{"tx", "watch", "eval", "lock:{lock}"} {
+		if !master.Exists(key) || replica.Exists(key) {
+			t.Errorf("%s: master %v, replica %v", key, master.Exists(key), replica.Exists(key))
+		}
+	}
+
+	// 实例不存在时 GetMaster 返回错误
+	if c, err := GetMaster("missing"); err == nil || c != nil {
+		t.Fatalf("GetMaster(missing) = %v, %v", c, err)
+	}
+}
+
+func TestReplicaConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       Config
+		replica bool
+		ok      bool
+	}{
+		{"standalone", Config{Addr: "a"}, false, true},
+		{"standalone replica", Config{Addr: "a", RouteRandomly: true}, false, false},
+		{"sentinel", Config{Mode: SENTINEL, MasterName: "m", Slaves: []string{"s"}}, false, true},
+		{"sentinel replica only", Config{Mode: SENTINEL, MasterName: "m", Slaves: []string{"s"}, ReplicaOnly: true}, true, true},
+		{"sentinel read only", Config{Mode: SENTINEL, MasterName: "m", Slaves: []string{"s"}, ReadOnly: true}, true, false},
+		{"cluster read only", Config{Mode: CLUSTER, Slaves: []string{"s"}, ReadOnly: true}, true, true},
+		{"cluster replica only", Config{Mode: CLUSTER, Slaves: []string{"s"}, ReplicaOnly: true}, false, false},
+		{"both routes", Config{Mode: CLUSTER, Slaves: []string{"s"}, RouteByLatency: true, RouteRandomly: true}, true, false},
+	}
+	for _, tt := range tests {
+		err := tt.c.validate()
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: validate() = %v", tt.name, err)
+		}
+		if err == nil && tt.c.replicaReads() != tt.replica {
+			t.Errorf("%s: replicaReads() = %v", tt.name, tt.c.replicaReads())
+		}
+	}
+}
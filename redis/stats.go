@@ -0,0 +1,57 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/restoflife/ql_common/logger"
+	"go.uber.org/zap"
+)
+
+// Stats 返回所有 Redis 实例的连接池统计，开启副本读的实例的主节点客户端以 <name>:master 返回
+func Stats() map[string]*redis.PoolStats {
+	result := make(map[string]*redis.PoolStats, len(redisMgr))
+	for name, client := range redisMgr {
+		result[name] = client.PoolStats()
+	}
+	for name, client := range masterMgr {
+		result[name+":master"] = client.PoolStats()
+	}
+	return result
+}
+
+// DefaultStatsInterval ReportStats 的默认输出间隔
+const DefaultStatsInterval = time.Minute
+
+// ReportStats 按指定间隔输出 Redis 连接池统计，ctx 取消后停止，interval 小于等于 0 时使用 DefaultStatsInterval
+func ReportStats(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultStatsInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for name, s := range Stats() {
+					logger.Info("Redis连接池统计",
+						zap.String("name", name),
+						zap.Uint32("hits", s.Hits),
+						zap.Uint32("misses", s.Misses),
+						zap.Uint32("timeouts", s.Timeouts),
+						zap.Uint32("wait_count", s.WaitCount),
+						zap.Duration("wait_duration", time.Duration(s.WaitDurationNs)),
+						zap.Uint32("total_conns", s.TotalConns),
+						zap.Uint32("idle_conns", s.IdleConns),
+						zap.Uint32("stale_conns", s.StaleConns),
+						zap.Uint32("pending_requests", s.PendingRequests),
+					)
+				}
+			}
+		}
+	}()
+}
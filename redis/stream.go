@@ -0,0 +1,430 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/restoflife/ql_common/logger"
+	"go.uber.org/zap"
+)
+
+// StreamConsumer 默认参数
+const (
+	DefaultStreamConcurrency   = 1                // 并发处理数
+	DefaultStreamBatchSize     = 10               // 每次读取的消息数
+	DefaultStreamBlock         = 2 * time.Second  // 无消息时的阻塞等待时间，也是关闭时的最长等待
+	DefaultStreamClaimIdle     = 30 * time.Second // 待确认消息空闲超过该时间后被重新投递
+	DefaultStreamMaxDeliveries = 5                // 投递次数超过该值后转入死信流
+	streamErrorBackoff         = time.Second      // 读取失败后的重试间隔
+)
+
+// StreamProducer 向 Stream 写入消息，写入时按 MAXLEN 近似裁剪
+type StreamProducer struct {
+	handle *Handle
+	stream string
+	maxLen int64
+}
+
+// NewStreamProducer 创建 Stream 生产者，maxLen 小于等于 0 时不裁剪
+func (h *Handle) NewStreamProducer(stream string, maxLen int64) *StreamProducer {
+	return &StreamProducer{handle: h, stream: stream, maxLen: maxLen}
+}
+
+// NewStreamProducer 创建指定实例上的 Stream 生产者
+func NewStreamProducer(name, stream string, maxLen int64) *StreamProducer {
+	return Use(name).NewStreamProducer(stream, maxLen)
+}
+
+// Add 写入一条消息，返回消息 ID
+//
+//	id, err := producer.Add(ctx, map[string]any{"order_id": 1001, "event": "paid"})
+func (p *StreamProducer) Add(ctx context.Context, values map[string]any) (string, error) {
+	if p.handle.err != nil {
+		return "", p.handle.err
+	}
+	args := &redis.XAddArgs{Stream: p.stream, Values: values}
+	if p.maxLen > 0 {
+		args.MaxLen = p.maxLen
+		args.Approx = true
+	}
+	return p.handle.pick(WithMaster(ctx)).XAdd(ctx, args).Result()
+}
+
+// StreamHandler 消息处理函数，返回 nil 时确认消息，返回错误时消息留在待确认列表等待重新投递
+type StreamHandler func(ctx context.Context, msg redis.XMessage) error
+
+// streamOptions StreamConsumer 的可选参数
+type streamOptions struct {
+	consumer      string
+	concurrency   int
+	batchSize     int64
+	block         time.Duration
+	claimIdle     time.Duration
+	maxDeliveries int64
+	deadLetter    string
+	startID       string
+	log           *zap.Logger
+}
+
+// StreamOption 是对 StreamConsumer 的函数式配置
+type StreamOption func(*streamOptions)
+
+// WithStreamConsumer 设置消费者名称，默认为 <主机名>-<进程号>
+func WithStreamConsumer(consumer string) StreamOption {
+	return func(o *streamOptions) {
+		o.consumer = consumer
+	}
+}
+
+// WithStreamConcurrency 设置并发处理数（默认 1）
+func WithStreamConcurrency(n int) StreamOption {
+	return func(o *streamOptions) {
+		o.concurrency = n
+	}
+}
+
+// WithStreamBatchSize 设置每次读取的消息数（默认 10）
+func WithStreamBatchSize(n int64) StreamOption {
+	return func(o *streamOptions) {
+		o.batchSize = n
+	}
+}
+
+// WithStreamBlock 设置无消息时的阻塞等待时间（默认 2 秒），小于等于 0 时使用默认值，最小 1 毫秒
+// BLOCK 0 会永久阻塞，ctx 取消后也无法返回
+func WithStreamBlock(d time.Duration) StreamOption {
+	return func(o *streamOptions) {
+		o.block = d
+	}
+}
+
+// WithStreamClaimIdle 设置待确认消息被重新投递前的空闲时间（默认 30 秒），需大于单条消息的最长处理时间
+func WithStreamClaimIdle(d time.Duration) StreamOption {
+	return func(o *streamOptions) {
+		o.claimIdle = d
+	}
+}
+
+// WithStreamMaxDeliveries 设置最大投递次数（默认 5），超过后转入死信流；小于等于 0 时不转入死信流
+func WithStreamMaxDeliveries(n int64) StreamOption {
+	return func(o *streamOptions) {
+		o.maxDeliveries = n
+	}
+}
+
+// WithStreamDeadLetter 设置死信流名称，默认为 <stream>:dead
+func WithStreamDeadLetter(stream string) StreamOption {
+	return func(o *streamOptions) {
+		o.deadLetter = stream
+	}
+}
+
+// WithStreamStartID 设置消费组不存在时的起始 ID（默认 "0" 从头消费，"$" 只消费新消息）
+func WithStreamStartID(id string) StreamOption {
+	return func(o *streamOptions) {
+		o.startID = id
+	}
+}
+
+// WithStreamLogger 设置日志实例，默认为 logger.Logger()，未初始化时不输出
+func WithStreamLogger(log *zap.Logger) StreamOption {
+	return func(o *streamOptions) {
+		o.log = log
+	}
+}
+
+// StreamConsumer 消费组工作者：读取新消息并发处理，成功后确认；
+// 处理失败的消息留在待确认列表，空闲超过 ClaimIdle 后通过 XAUTOCLAIM 重新投递（可能投递给其他进程），
+// 投递次数超过 MaxDeliveries 后写入死信流并确认
+//
+//	consumer := redis.NewStreamConsumer("mq", "order:events", "billing", handle,
+//		redis.WithStreamConcurrency(8))
+//	go consumer.Run(ctx) // ctx 取消后等待处理中的消息完成再返回
+type StreamConsumer struct {
+	handle  *Handle
+	stream  string
+	group   string
+	handler StreamHandler
+	opts    streamOptions
+}
+
+// NewStreamConsumer 创建消费组工作者
+func (h *Handle) NewStreamConsumer(stream, group string, handler StreamHandler, opts ...StreamOption) *StreamConsumer {
+	o := streamOptions{
+		concurrency:   DefaultStreamConcurrency,
+		batchSize:     DefaultStreamBatchSize,
+		block:         DefaultStreamBlock,
+		claimIdle:     DefaultStreamClaimIdle,
+		maxDeliveries: DefaultStreamMaxDeliveries,
+		deadLetter:    stream + ":dead",
+		startID:       "0",
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.consumer == "" {
+		host, _ := os.Hostname()
+		o.consumer = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	o.concurrency = max(o.concurrency, 1)
+	o.batchSize = max(o.batchSize, 1)
+	if o.block <= 0 {
+		o.block = DefaultStreamBlock
+	}
+	o.block = max(o.block, time.Millisecond)
+	return &StreamConsumer{handle: h, stream: stream, group: group, handler: handler, opts: o}
+}
+
+// NewStreamConsumer 创建指定实例上的消费组工作者
+func NewStreamConsumer(name, stream, group string, handler StreamHandler, opts ...StreamOption) *StreamConsumer {
+	return Use(name).NewStreamConsumer(stream, group, handler, opts...)
+}
+
+// Run 创建消费组（已存在时忽略）并开始消费，阻塞直到 ctx 取消且处理中的消息全部完成
+// 已读取但未开始处理的消息留在待确认列表，之后重新投递
+func (c *StreamConsumer) Run(ctx context.Context) error {
+	if c.handle.err != nil {
+		return c.handle.err
+	}
+	if c.opts.log == nil {
+		c.opts.log = logger.Logger()
+	}
+	if c.opts.log == nil {
+		c.opts.log = zap.NewNop()
+	}
+	if err := c.createGroup(ctx); err != nil {
+		return err
+	}
+	c.opts.log.Info("Stream消费者启动", c.fields()...)
+
+	msgs := make(chan redis.XMessage)
+	// 处理中的消息不随 ctx 取消
+	workCtx := context.WithoutCancel(ctx)
+	var workers sync.WaitGroup
+	for range c.opts.concurrency {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for msg := range msgs {
+				c.process(workCtx, msg)
+			}
+		}()
+	}
+
+	var loops sync.WaitGroup
+	loops.Add(2)
+	go func() {
+		defer loops.Done()
+		c.read(ctx, msgs)
+	}()
+	go func() {
+		defer loops.Done()
+		c.reclaim(ctx, msgs)
+	}()
+	loops.Wait()
+	close(msgs)
+	workers.Wait()
+
+	c.opts.log.Info("Stream消费者已停止", c.fields()...)
+	return nil
+}
+
+// client 消费组命令均需在主节点执行
+func (c *StreamConsumer) client(ctx context.Context) redis.UniversalClient {
+	return c.handle.pick(WithMaster(ctx))
+}
+
+// createGroup 创建消费组，Stream 不存在时一并创建
+func (c *StreamConsumer) createGroup(ctx context.Context) error {
+	err := c.client(ctx).XGroupCreateMkStream(ctx, c.stream, c.group, c.opts.startID).Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	return nil
+}
+
+// read 读取新消息
+func (c *StreamConsumer) read(ctx context.Context, out chan<- redis.XMessage) {
+	for ctx.Err() == nil {
+		streams, err := c.client(ctx).XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    c.group,
+			Consumer: c.opts.consumer,
+			Streams:  []string{c.stream, ">"},
+			Count:    c.opts.batchSize,
+			Block:    c.opts.block,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) || ctx.Err() != nil {
+				continue
+			}
+			c.opts.log.Warn("Stream读取失败", append(c.fields(), zap.Error(err))...)
+			// Stream 或消费组被删除时重新创建
+			if strings.HasPrefix(err.Error(), "NOGROUP") {
+				_ = c.createGroup(ctx)
+			}
+			sleepContext(ctx, streamErrorBackoff)
+			continue
+		}
+		for _, s := range streams {
+			for _, msg := range s.Messages {
+				select {
+				case out <- msg:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}
+}
+
+// reclaim 定期认领空闲的待确认消息，投递次数超限的转入死信流
+func (c *StreamConsumer) reclaim(ctx context.Context, out chan<- redis.XMessage) {
+	ticker := time.NewTicker(max(c.opts.claimIdle/2, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		start := "0-0"
+		for ctx.Err() == nil {
+			msgs, next, err := c.client(ctx).XAutoClaim(ctx, &redis.XAutoClaimArgs{
+				Stream:   c.stream,
+				Group:    c.group,
+				Consumer: c.opts.consumer,
+				MinIdle:  c.opts.claimIdle,
+				Start:    start,
+				Count:    c.opts.batchSize,
+			}).Result()
+			if err != nil {
+				if ctx.Err() == nil {
+					c.opts.log.Warn("Stream认领待确认消息失败", append(c.fields(), zap.Error(err))...)
+				}
+				break
+			}
+			if len(msgs) > 0 {
+				deliveries := c.deliveries(ctx, msgs)
+				for _, msg := range msgs {
+					if n := deliveries[msg.ID]; c.opts.maxDeliveries > 0 && n > c.opts.maxDeliveries {
+						c.deadLetter(ctx, msg, n)
+						continue
+					}
+					select {
+					case out <- msg:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+			if next == "0-0" || next == "" {
+				break
+			}
+			start = next
+		}
+	}
+}
+
+// deliveries 逐条查询已认领消息的投递次数（按区间查询可能因其他消费者的消息占用名额而遗漏）
+func (c *StreamConsumer) deliveries(ctx context.Context, msgs []redis.XMessage) map[string]int64 {
+	result := make(map[string]int64, len(msgs))
+	cmds := make([]*redis.XPendingExtCmd, len(msgs))
+	_, err := c.client(ctx).Pipelined(ctx, func(p redis.Pipeliner) error {
+		for i, msg := range msgs {
+			cmds[i] = p.XPendingExt(ctx, &redis.XPendingExtArgs{
+				Stream: c.stream,
+				Group:  c.group,
+				Start:  msg.ID,
+				End:    msg.ID,
+				Count:  1,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		c.opts.log.Warn("Stream查询投递次数失败", append(c.fields(), zap.Error(err))...)
+		return result
+	}
+	for _, cmd := range cmds {
+		for _, p := range cmd.Val() {
+			result[p.ID] = p.RetryCount
+		}
+	}
+	return result
+}
+
+// deadLetter 写入死信流并确认原消息，死信中附带来源信息
+func (c *StreamConsumer) deadLetter(ctx context.Context, msg redis.XMessage, deliveries int64) {
+	values := make(map[string]any, len(msg.Values)+4)
+	for k, v := range msg.Values {
+		values[k] = v
+	}
+	values["_source_stream"] = c.stream
+	values["_source_id"] = msg.ID
+	values["_group"] = c.group
+	values["_deliveries"] = deliveries
+
+	client := c.client(ctx)
+	err := client.XAdd(ctx, &redis.XAddArgs{Stream: c.opts.deadLetter, Values: values}).Err()
+	if err == nil {
+		err = client.XAck(ctx, c.stream, c.group, msg.ID).Err()
+	}
+	fields := append(c.fields(), zap.String("id", msg.ID), zap.Int64("deliveries", deliveries), zap.String("dead_letter", c.opts.deadLetter))
+	if err != nil {
+		c.opts.log.Error("Stream消息转入死信失败", append(fields, zap.Error(err))...)
+		return
+	}
+	c.opts.log.Warn("Stream消息转入死信", fields...)
+}
+
+// process 处理一条消息，成功后确认
+func (c *StreamConsumer) process(ctx context.Context, msg redis.XMessage) {
+	start := time.Now()
+	if err := c.call(ctx, msg); err != nil {
+		c.opts.log.Warn("Stream消息处理失败", append(c.fields(),
+			zap.String("id", msg.ID), zap.Duration("cost", time.Since(start)), zap.Error(err))...)
+		return
+	}
+	if err := c.client(ctx).XAck(ctx, c.stream, c.group, msg.ID).Err(); err != nil {
+		c.opts.log.Warn("Stream消息确认失败", append(c.fields(), zap.String("id", msg.ID), zap.Error(err))...)
+	}
+}
+
+// call 调用处理函数，panic 视为处理失败
+func (c *StreamConsumer) call(ctx context.Context, msg redis.XMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+			c.opts.log.Error("Stream消息处理panic", append(c.fields(),
+				zap.String("id", msg.ID), zap.Any("panic", r), zap.ByteString("stack", debug.Stack()))...)
+		}
+	}()
+	return c.handler(ctx, msg)
+}
+
+func (c *StreamConsumer) fields() []zap.Field {
+	return []zap.Field{
+		zap.String("name", c.handle.name),
+		zap.String("stream", c.stream),
+		zap.String("group", c.group),
+		zap.String("consumer", c.opts.consumer),
+	}
+}
+
+// sleepContext 等待 d 或 ctx 取消
+func sleepContext(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
@@ -0,0 +1,71 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestStreamDeadLetter(t *testing.T) {
+	newTestRedis(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, err := NewStreamProducer("default", "events", 100).Add(ctx, map[string]any{"n": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 处理一直失败，投递 2 次后转入死信流
+	var calls atomic.Int32
+	c := NewStreamConsumer("default", "events", "g", func(context.Context, redis.XMessage) error {
+		calls.Add(1)
+		return errors.New("fail")
+	}, WithStreamClaimIdle(10*time.Millisecond), WithStreamMaxDeliveries(2), WithStreamBlock(50*time.Millisecond))
+	done := make(chan error, 1)
+	go func() { done <- c.Run(ctx) }()
+
+	client, _ := GetRedis("default")
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if n, _ := client.XLen(ctx, "events:dead").Result(); n == 1 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil && !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+
+	dead, err := client.XRange(context.Background(), "events:dead", "-", "+").Result()
+	if err != nil || len(dead) != 1 {
+		t.Fatalf("dead letters = %v, %v", dead, err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("handler calls = %d, want 2", n)
+	}
+	pending, _ := client.XPending(context.Background(), "events", "g").Result()
+	if pending.Count != 0 {
+		t.Fatalf("pending = %d, want 0", pending.Count)
+	}
+}
+
+func TestStreamBlockOption(t *testing.T) {
+	for _, tc := range []struct {
+		block, want time.Duration
+	}{
+		{0, DefaultStreamBlock},
+		{-time.Second, DefaultStreamBlock},
+		{time.Microsecond, time.Millisecond},
+		{time.Second, time.Second},
+	} {
+		c := NewStreamConsumer("default", "s", "g", nil, WithStreamBlock(tc.block))
+		if c.opts.block != tc.want {
+			t.Errorf("WithStreamBlock(%v) = %v, want %v", tc.block, c.opts.block, tc.want)
+		}
+	}
+}